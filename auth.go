@@ -1,7 +1,10 @@
 package promptSDK
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier"
 )
 
@@ -14,10 +17,86 @@ const (
 	CourseStudent  = keycloakTokenVerifier.CourseStudent
 )
 
-func InitAuthenticationMiddleware(KeycloakURL, Realm, CoreURL string) error {
-	return keycloakTokenVerifier.InitKeycloakTokenVerifier(KeycloakURL, Realm, CoreURL)
+// ErrNotStudent is returned by the core requests if the user is not a student of the course. Use it with errors.Is.
+var ErrNotStudent = keycloakTokenVerifier.ErrNotStudent
+
+// AuthOption configures the authentication middleware, see the With* options of keycloakTokenVerifier.
+type AuthOption = keycloakTokenVerifier.Option
+
+func InitAuthenticationMiddleware(KeycloakURL, Realm, CoreURL string, opts ...AuthOption) error {
+	return keycloakTokenVerifier.InitKeycloakTokenVerifier(KeycloakURL, Realm, CoreURL, opts...)
+}
+
+// NewAuthVerifier creates an independent verifier, e.g. for a second realm. Its middlewares are methods of the verifier.
+func NewAuthVerifier(KeycloakURL, Realm, CoreURL string, opts ...AuthOption) (*keycloakTokenVerifier.Verifier, error) {
+	return keycloakTokenVerifier.NewVerifier(KeycloakURL, Realm, CoreURL, opts...)
+}
+
+// VerifierStatus is the state of the OIDC discovery and the signing keys of the authentication middleware.
+type VerifierStatus = keycloakTokenVerifier.VerifierStatus
+
+// AuthHealthy reports whether the authentication middleware can verify tokens, e.g. for a readiness probe.
+func AuthHealthy() bool {
+	return keycloakTokenVerifier.Healthy()
+}
+
+// AuthStatus returns the state of the OIDC discovery and the signing keys of the authentication middleware.
+func AuthStatus() VerifierStatus {
+	return keycloakTokenVerifier.Status()
 }
 
 func AuthenticationMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return keycloakTokenVerifier.AuthenticationMiddleware(allowedRoles...)
 }
+
+// UseIntrospection makes the middlewares of the following handlers also check the token with Keycloak's
+// introspection endpoint, configured with keycloakTokenVerifier.WithIntrospection.
+func UseIntrospection() gin.HandlerFunc {
+	return keycloakTokenVerifier.UseIntrospection()
+}
+
+// UseAudiences makes the middlewares of the following handlers require one of the audiences in the token.
+func UseAudiences(audiences ...string) gin.HandlerFunc {
+	return keycloakTokenVerifier.UseAudiences(audiences...)
+}
+
+// UseAuthorizedParties makes the middlewares of the following handlers accept these azp values instead of the configured ones.
+func UseAuthorizedParties(authorizedParties ...string) gin.HandlerFunc {
+	return keycloakTokenVerifier.UseAuthorizedParties(authorizedParties...)
+}
+
+// TokenClaimError reports a token whose aud or azp claim is not allowed for the route.
+type TokenClaimError = keycloakTokenVerifier.TokenClaimError
+
+// ServicePrincipal is the caller of a request authenticated with the client-credentials token of another service.
+type ServicePrincipal = keycloakTokenVerifier.ServicePrincipal
+
+// ServiceMiddleware accepts only client-credentials tokens of the service accounts of allowedClients.
+// Read the caller with GetServicePrincipal.
+func ServiceMiddleware(allowedClients ...string) gin.HandlerFunc {
+	return keycloakTokenVerifier.ServiceMiddleware(allowedClients...)
+}
+
+func GetServicePrincipal(c *gin.Context) (ServicePrincipal, bool) {
+	return keycloakTokenVerifier.GetServicePrincipal(c)
+}
+
+// Problem is the problem+json body of the error responses of the authentication middlewares.
+type Problem = keycloakTokenVerifier.Problem
+
+// Policy is an authorization rule built with keycloakTokenVerifier.All, Any, Not, Role and Predicate.
+type Policy = keycloakTokenVerifier.Policy
+
+// PolicyMiddleware authenticates the request and grants access if the policy allows it.
+func PolicyMiddleware(policy Policy) gin.HandlerFunc {
+	return keycloakTokenVerifier.PolicyMiddleware(policy)
+}
+
+// CoursePhaseAccess is the lecturer, editor, custom and student status of a user in one course phase.
+type CoursePhaseAccess = keycloakTokenVerifier.CoursePhaseAccess
+
+// ResolveCoursePhaseAccess resolves the access of the user to several course phases at once, e.g. for a course overview.
+// Enrich the TokenUser of a course phase with TokenUser.WithCoursePhaseAccess.
+func ResolveCoursePhaseAccess(ctx context.Context, authHeader string, tokenUser keycloakTokenVerifier.TokenUser, coursePhaseIDs []uuid.UUID) (map[uuid.UUID]CoursePhaseAccess, error) {
+	return keycloakTokenVerifier.ResolveCoursePhaseAccess(ctx, authHeader, tokenUser, coursePhaseIDs)
+}
@@ -0,0 +1,172 @@
+package coretest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	promptSDK "github.com/ls1intum/prompt-sdk"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	"github.com/ls1intum/prompt-sdk/promptTypes"
+)
+
+// middleware records the request, applies configured failures and rejects requests without an Authorization header.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		statusCode, fail := s.failures[r.URL.Path]
+		s.mu.Unlock()
+
+		if fail {
+			writeError(w, statusCode, "configured failure")
+			return
+		}
+		if r.Header.Get("Authorization") == "" {
+			writeError(w, http.StatusUnauthorized, "authorization header missing")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleRoles(w http.ResponseWriter, r *http.Request) {
+	s.withPhase(w, r, func(phase *coursePhase) {
+		if phase.roleMapping == nil {
+			writeError(w, http.StatusNotFound, "no role mapping for course phase")
+			return
+		}
+		writeJSON(w, http.StatusOK, phase.roleMapping)
+	})
+}
+
+func (s *Server) handleIsStudent(w http.ResponseWriter, r *http.Request) {
+	subject, ok := s.SubjectFromAuthHeader(r.Header.Get("Authorization"))
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "could not identify user")
+		return
+	}
+	s.withPhase(w, r, func(phase *coursePhase) {
+		participation, isStudent := phase.students[subject]
+		if !isStudent {
+			writeError(w, http.StatusUnauthorized, "not student of course")
+			return
+		}
+		writeJSON(w, http.StatusOK, participation)
+	})
+}
+
+// handleCoursePhasesAccess serves the batch variant of the roles and is_student endpoints.
+// Unknown course phases are omitted from the response.
+func (s *Server) handleCoursePhasesAccess(w http.ResponseWriter, r *http.Request) {
+	subject, ok := s.SubjectFromAuthHeader(r.Header.Get("Authorization"))
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "could not identify user")
+		return
+	}
+	var request keycloakTokenVerifierDTO.GetCoursePhasesAccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.batchDisabled {
+		writeError(w, http.StatusNotFound, "404 page not found")
+		return
+	}
+
+	response := make(map[uuid.UUID]keycloakTokenVerifierDTO.GetCoursePhaseAccess, len(request.CoursePhaseIDs))
+	for _, coursePhaseID := range request.CoursePhaseIDs {
+		phase, ok := s.phases[coursePhaseID]
+		if !ok {
+			continue
+		}
+		var access keycloakTokenVerifierDTO.GetCoursePhaseAccess
+		if phase.roleMapping != nil {
+			access.Roles = *phase.roleMapping
+		}
+		access.Participation, access.IsStudentOfCourse = phase.students[subject]
+		response[coursePhaseID] = access
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleParticipations(w http.ResponseWriter, r *http.Request) {
+	s.withPhase(w, r, func(phase *coursePhase) {
+		writeJSON(w, http.StatusOK, promptSDK.CoursePhaseParticipationsWithResolutions{
+			Participations: nonNil(phase.participations),
+			Resolutions:    nonNil(phase.resolutions),
+		})
+	})
+}
+
+func (s *Server) handleParticipation(w http.ResponseWriter, r *http.Request) {
+	courseParticipationID, err := uuid.Parse(r.PathValue("courseParticipationID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.withPhase(w, r, func(phase *coursePhase) {
+		for _, participation := range phase.participations {
+			if participation.CourseParticipationID == courseParticipationID {
+				writeJSON(w, http.StatusOK, promptSDK.CoursePhaseParticipationWithResolutions{
+					Participation: participation,
+					Resolutions:   nonNil(phase.resolutions),
+				})
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "participation not found")
+	})
+}
+
+func (s *Server) handleCoursePhaseData(w http.ResponseWriter, r *http.Request) {
+	s.withPhase(w, r, func(phase *coursePhase) {
+		prevData := phase.prevData
+		if prevData == nil {
+			prevData = promptTypes.MetaData{}
+		}
+		writeJSON(w, http.StatusOK, promptSDK.PrevCoursePhaseData{
+			PrevData:    prevData,
+			Resolutions: nonNil(phase.resolutions),
+		})
+	})
+}
+
+// withPhase calls handle with the course phase of the request while holding a read lock,
+// or responds with 404 if the course phase does not exist.
+func (s *Server) withPhase(w http.ResponseWriter, r *http.Request, handle func(phase *coursePhase)) {
+	coursePhaseID, err := uuid.Parse(r.PathValue("coursePhaseID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	phase, ok := s.phases[coursePhaseID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "course phase not found")
+		return
+	}
+	handle(phase)
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]string{"error": message})
+}
@@ -0,0 +1,149 @@
+package coretest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	promptSDK "github.com/ls1intum/prompt-sdk"
+)
+
+// ModuleServer is a fake Prompt module serving resolution endpoints, i.e.
+//
+//	GET /course_phase/:coursePhaseID/:endpointPath                         -> {"<dtoName>": ...} or [{"courseParticipationID": ..., "<dtoName>": ...}]
+//	GET /course_phase/:coursePhaseID/:endpointPath/:courseParticipationID  -> {"<dtoName>": ...}
+//
+// Use Resolution to reference an endpoint from the fake core. All methods are safe for concurrent use.
+type ModuleServer struct {
+	server *httptest.Server
+
+	mu        sync.RWMutex
+	endpoints map[endpointKey]*endpoint
+}
+
+type endpointKey struct {
+	coursePhaseID uuid.UUID
+	endpointPath  string
+}
+
+type endpoint struct {
+	dtoName string
+	// coursePhaseData is served if the endpoint holds no participation data.
+	coursePhaseData interface{}
+	participations  []uuid.UUID
+	participantData map[uuid.UUID]interface{}
+}
+
+// NewModuleServer starts a fake module without any endpoints. The caller must Close the server.
+func NewModuleServer() *ModuleServer {
+	m := &ModuleServer{endpoints: make(map[endpointKey]*endpoint)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /course_phase/{coursePhaseID}/{endpointPath...}", m.handleResolution)
+	m.server = httptest.NewServer(mux)
+	return m
+}
+
+// URL returns the base URL of the module, as used in Resolution.BaseURL.
+func (m *ModuleServer) URL() string {
+	return m.server.URL
+}
+
+// Close shuts down the server.
+func (m *ModuleServer) Close() {
+	m.server.Close()
+}
+
+// Resolution returns a resolution of the dtoName served at endpointPath of the course phase.
+func (m *ModuleServer) Resolution(coursePhaseID uuid.UUID, endpointPath, dtoName string) promptSDK.Resolution {
+	return promptSDK.Resolution{
+		DtoName:       dtoName,
+		BaseURL:       m.server.URL,
+		EndpointPath:  endpointPath,
+		CoursePhaseID: coursePhaseID,
+	}
+}
+
+// SetCoursePhaseData serves {"<dtoName>": data} at endpointPath of the course phase.
+func (m *ModuleServer) SetCoursePhaseData(coursePhaseID uuid.UUID, endpointPath, dtoName string, data interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.endpoint(coursePhaseID, endpointPath, dtoName)
+	e.coursePhaseData = data
+}
+
+// AddParticipationData adds the DTO of a participation served at endpointPath of the course phase,
+// both in the list of all participations and at the endpoint of the single participation.
+func (m *ModuleServer) AddParticipationData(coursePhaseID uuid.UUID, endpointPath, dtoName string, courseParticipationID uuid.UUID, data interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.endpoint(coursePhaseID, endpointPath, dtoName)
+	if _, exists := e.participantData[courseParticipationID]; !exists {
+		e.participations = append(e.participations, courseParticipationID)
+	}
+	e.participantData[courseParticipationID] = data
+}
+
+// endpoint returns the endpoint, creating it if necessary. The caller must hold m.mu.
+func (m *ModuleServer) endpoint(coursePhaseID uuid.UUID, endpointPath, dtoName string) *endpoint {
+	key := endpointKey{coursePhaseID: coursePhaseID, endpointPath: strings.Trim(endpointPath, "/")}
+	e, ok := m.endpoints[key]
+	if !ok {
+		e = &endpoint{participantData: make(map[uuid.UUID]interface{})}
+		m.endpoints[key] = e
+	}
+	e.dtoName = dtoName
+	return e
+}
+
+func (m *ModuleServer) handleResolution(w http.ResponseWriter, r *http.Request) {
+	coursePhaseID, err := uuid.Parse(r.PathValue("coursePhaseID"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	endpointPath := strings.Trim(r.PathValue("endpointPath"), "/")
+	if e, ok := m.endpoints[endpointKey{coursePhaseID: coursePhaseID, endpointPath: endpointPath}]; ok {
+		if len(e.participations) == 0 {
+			writeJSON(w, http.StatusOK, map[string]interface{}{e.dtoName: e.coursePhaseData})
+			return
+		}
+		items := make([]map[string]interface{}, 0, len(e.participations))
+		for _, courseParticipationID := range e.participations {
+			items = append(items, map[string]interface{}{
+				"courseParticipationID": courseParticipationID,
+				e.dtoName:               e.participantData[courseParticipationID],
+			})
+		}
+		writeJSON(w, http.StatusOK, items)
+		return
+	}
+
+	// single participation: the last path segment is the courseParticipationID
+	idx := strings.LastIndex(endpointPath, "/")
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, "endpoint not found")
+		return
+	}
+	courseParticipationID, err := uuid.Parse(endpointPath[idx+1:])
+	if err != nil {
+		writeError(w, http.StatusNotFound, "endpoint not found")
+		return
+	}
+	e, ok := m.endpoints[endpointKey{coursePhaseID: coursePhaseID, endpointPath: endpointPath[:idx]}]
+	if !ok {
+		writeError(w, http.StatusNotFound, "endpoint not found")
+		return
+	}
+	data, ok := e.participantData[courseParticipationID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "participation not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{e.dtoName: data})
+}
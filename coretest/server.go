@@ -0,0 +1,184 @@
+// Package coretest provides an in-process fake of the Prompt Core for integration tests of Prompt modules.
+//
+// The Server serves the authentication (including the batch endpoint) and course phase endpoints used by the SDK from a programmable,
+// in-memory model of course phases, so that the AuthenticationMiddleware and the FetchAndMerge* helpers
+// can be tested without a running core:
+//
+//	core := coretest.NewServer()
+//	defer core.Close()
+//	core.SetRoleMapping(coursePhaseID, keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios25-Lecturer"})
+//	core.AddStudent(coursePhaseID, subject, keycloakTokenVerifierDTO.GetCoursePhaseParticipation{...})
+//	core.AddParticipation(coursePhaseID, participation)
+//
+//	_ = keycloakTokenVerifier.InitKeycloakTokenVerifier(keycloakURL, realm, core.URL())
+package coretest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	promptSDK "github.com/ls1intum/prompt-sdk"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	"github.com/ls1intum/prompt-sdk/promptTypes"
+)
+
+// Server is a fake Prompt Core. All methods are safe for concurrent use.
+type Server struct {
+	// SubjectFromAuthHeader resolves the user of a request. By default, the sub claim of the
+	// bearer token is read WITHOUT verifying the token.
+	SubjectFromAuthHeader func(authHeader string) (string, bool)
+
+	server *httptest.Server
+
+	mu            sync.RWMutex
+	phases        map[uuid.UUID]*coursePhase
+	failures      map[string]int
+	requests      map[string]int
+	batchDisabled bool
+}
+
+type coursePhase struct {
+	roleMapping    *keycloakTokenVerifierDTO.GetCourseRoles
+	students       map[string]keycloakTokenVerifierDTO.GetCoursePhaseParticipation
+	participations []promptTypes.CoursePhaseParticipationWithStudent
+	resolutions    []promptSDK.Resolution
+	prevData       promptTypes.MetaData
+}
+
+// NewServer starts a fake core without any course phases. The caller must Close the server.
+func NewServer() *Server {
+	s := &Server{
+		SubjectFromAuthHeader: SubjectFromBearerToken,
+		phases:                make(map[uuid.UUID]*coursePhase),
+		failures:              make(map[string]int),
+		requests:              make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/auth/course_phase/{coursePhaseID}/roles", s.handleRoles)
+	mux.HandleFunc("GET /api/auth/course_phase/{coursePhaseID}/is_student", s.handleIsStudent)
+	mux.HandleFunc("POST /api/auth/course_phases/access", s.handleCoursePhasesAccess)
+	mux.HandleFunc("GET /api/course_phases/{coursePhaseID}/participations", s.handleParticipations)
+	mux.HandleFunc("GET /api/course_phases/{coursePhaseID}/participations/{courseParticipationID}", s.handleParticipation)
+	mux.HandleFunc("GET /api/course_phases/{coursePhaseID}/course_phase_data", s.handleCoursePhaseData)
+	s.server = httptest.NewServer(s.middleware(mux))
+	return s
+}
+
+// URL returns the base URL of the fake core, as passed to InitKeycloakTokenVerifier and the FetchAndMerge* helpers.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// SetRoleMapping sets the Keycloak role names of a course phase, returned by /api/auth/course_phase/:id/roles.
+func (s *Server) SetRoleMapping(coursePhaseID uuid.UUID, roles keycloakTokenVerifierDTO.GetCourseRoles) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase(coursePhaseID).roleMapping = &roles
+}
+
+// AddStudent makes the user with the given subject a student of the course of the course phase.
+// Whether the user also participates in the phase itself is set with participation.IsStudentOfCoursePhase.
+func (s *Server) AddStudent(coursePhaseID uuid.UUID, subject string, participation keycloakTokenVerifierDTO.GetCoursePhaseParticipation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase(coursePhaseID).students[subject] = participation
+}
+
+// AddParticipation adds a participation to the course phase.
+func (s *Server) AddParticipation(coursePhaseID uuid.UUID, participation promptTypes.CoursePhaseParticipationWithStudent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	phase := s.phase(coursePhaseID)
+	phase.participations = append(phase.participations, participation)
+}
+
+// AddResolution adds a resolution, returned with the participations and the course phase data.
+func (s *Server) AddResolution(coursePhaseID uuid.UUID, resolution promptSDK.Resolution) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	phase := s.phase(coursePhaseID)
+	phase.resolutions = append(phase.resolutions, resolution)
+}
+
+// SetCoursePhaseData sets the prevData returned by /api/course_phases/:id/course_phase_data.
+func (s *Server) SetCoursePhaseData(coursePhaseID uuid.UUID, prevData promptTypes.MetaData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase(coursePhaseID).prevData = prevData
+}
+
+// Fail makes all requests to the exact path (e.g. "/api/course_phases/<id>/participations") return statusCode.
+func (s *Server) Fail(path string, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = statusCode
+}
+
+// RequestCount returns how many requests were received for the exact path.
+func (s *Server) RequestCount(path string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.requests[path]
+}
+
+// SetBatchSupported enables (default) or disables the batch endpoint /api/auth/course_phases/access.
+// If disabled, the endpoint responds with 404 like a core without batch support.
+func (s *Server) SetBatchSupported(supported bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchDisabled = !supported
+}
+
+// Reset removes all course phases, failures and recorded requests and enables the batch endpoint again.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.phases)
+	clear(s.failures)
+	clear(s.requests)
+	s.batchDisabled = false
+}
+
+// phase returns the course phase, creating it if necessary. The caller must hold s.mu.
+func (s *Server) phase(coursePhaseID uuid.UUID) *coursePhase {
+	phase, ok := s.phases[coursePhaseID]
+	if !ok {
+		phase = &coursePhase{students: make(map[string]keycloakTokenVerifierDTO.GetCoursePhaseParticipation)}
+		s.phases[coursePhaseID] = phase
+	}
+	return phase
+}
+
+// SubjectFromBearerToken returns the sub claim of a bearer token without verifying the token.
+func SubjectFromBearerToken(authHeader string) (string, bool) {
+	token, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok {
+		return "", false
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", false
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return "", false
+	}
+	return claims.Subject, true
+}
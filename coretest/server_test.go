@@ -0,0 +1,466 @@
+package coretest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	promptSDK "github.com/ls1intum/prompt-sdk"
+	"github.com/ls1intum/prompt-sdk/coretest"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+	"github.com/ls1intum/prompt-sdk/promptTypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_AuthenticationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+
+	coursePhaseID := uuid.New()
+	courseParticipationID := uuid.New()
+	core.SetRoleMapping(coursePhaseID, keycloakTokenVerifierDTO.GetCourseRoles{
+		CourseLecturerRole: "ios25-Lecturer",
+		CourseEditorRole:   "ios25-Editor",
+		CustomRolePrefix:   "ios25-cg-",
+	})
+	core.AddStudent(coursePhaseID, "student", keycloakTokenVerifierDTO.GetCoursePhaseParticipation{
+		IsStudentOfCoursePhase: true,
+		CourseParticipationID:  courseParticipationID,
+	})
+
+	v, err := keycloakTokenVerifier.NewVerifier(kc.URL(), kc.Realm, core.URL())
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/course_phase/:coursePhaseID", v.AuthenticationMiddleware(keycloakTokenVerifier.CourseLecturer, keycloakTokenVerifier.CourseStudent), func(c *gin.Context) {
+		tokenUser, _ := keycloakTokenVerifier.GetTokenUser(c)
+		c.JSON(http.StatusOK, tokenUser)
+	})
+
+	tests := []struct {
+		name       string
+		authHeader string
+		wantStatus int
+		check      func(t *testing.T, tokenUser keycloakTokenVerifier.TokenUser)
+	}{
+		{
+			name:       "lecturer of course phase",
+			authHeader: kc.Token().Subject("lecturer").Roles("ios25-Lecturer").BearerHeader(),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, tokenUser keycloakTokenVerifier.TokenUser) {
+				assert.True(t, tokenUser.IsLecturer)
+				assert.Equal(t, "ios25-cg-", tokenUser.CustomRolePrefix)
+			},
+		},
+		{
+			name:       "student of course phase",
+			authHeader: kc.Token().Subject("student").BearerHeader(),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, tokenUser keycloakTokenVerifier.TokenUser) {
+				assert.True(t, tokenUser.IsStudentOfCoursePhase)
+				assert.Equal(t, courseParticipationID, tokenUser.CourseParticipationID)
+			},
+		},
+		{
+			name:       "neither lecturer nor student",
+			authHeader: kc.Token().Subject("someone").Roles("other-Lecturer").BearerHeader(),
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/course_phase/"+coursePhaseID.String(), nil)
+			req.Header.Set("Authorization", tt.authHeader)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.check != nil {
+				var tokenUser keycloakTokenVerifier.TokenUser
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tokenUser))
+				tt.check(t, tokenUser)
+			}
+		})
+	}
+}
+
+func TestServer_AuthenticationMiddleware_CoreFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+
+	v, err := keycloakTokenVerifier.NewVerifier(kc.URL(), kc.Realm, core.URL())
+	require.NoError(t, err)
+	router := gin.New()
+	router.GET("/course_phase/:coursePhaseID", v.AuthenticationMiddleware(keycloakTokenVerifier.CourseLecturer), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		coreStatus int
+		wantStatus int
+	}{
+		{"core rejects the token", http.StatusUnauthorized, http.StatusForbidden},
+		{"core denies access", http.StatusForbidden, http.StatusForbidden},
+		{"unknown course phase", http.StatusNotFound, http.StatusNotFound},
+		{"core fails", http.StatusInternalServerError, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			coursePhaseID := uuid.New()
+			core.SetRoleMapping(coursePhaseID, keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios25-Lecturer"})
+			core.Fail("/api/auth/course_phase/"+coursePhaseID.String()+"/roles", tt.coreStatus)
+
+			req := httptest.NewRequest(http.MethodGet, "/course_phase/"+coursePhaseID.String(), nil)
+			req.Header.Set("Authorization", kc.Token().Subject("lecturer").Roles("ios25-Lecturer").BearerHeader())
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			assert.NotContains(t, rec.Body.String(), core.URL(), "the upstream error is not exposed")
+		})
+	}
+}
+
+func TestServer_FetchAndMergeCoursePhaseWithResolution(t *testing.T) {
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+	module := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"teams":[{"name":"Team 1"}]}`))
+	}))
+	t.Cleanup(module.Close)
+
+	coursePhaseID := uuid.New()
+	core.SetCoursePhaseData(coursePhaseID, promptTypes.MetaData{"scoreThreshold": 3.0})
+	core.AddResolution(coursePhaseID, promptSDK.Resolution{
+		DtoName:       "teams",
+		BaseURL:       module.URL,
+		EndpointPath:  "teams",
+		CoursePhaseID: uuid.New(),
+	})
+
+	prevData, err := promptSDK.FetchAndMergeCoursePhaseWithResolution(core.URL(), "Bearer token", coursePhaseID)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, prevData["scoreThreshold"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "Team 1"}}, prevData["teams"])
+
+	_, err = promptSDK.FetchAndMergeCoursePhaseWithResolution(core.URL(), "", coursePhaseID)
+	assert.Error(t, err, "the fake core rejects requests without Authorization header")
+
+	core.Fail("/api/course_phases/"+coursePhaseID.String()+"/course_phase_data", http.StatusInternalServerError)
+	_, err = promptSDK.FetchAndMergeCoursePhaseWithResolution(core.URL(), "Bearer token", coursePhaseID)
+	assert.Error(t, err)
+	assert.Equal(t, 3, core.RequestCount("/api/course_phases/"+coursePhaseID.String()+"/course_phase_data"))
+}
+
+func TestServer_ResolveCoursePhaseAccess(t *testing.T) {
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	for _, batchSupported := range []bool{true, false} {
+		t.Run(fmt.Sprintf("batch supported %v", batchSupported), func(t *testing.T) {
+			core := coretest.NewServer()
+			t.Cleanup(core.Close)
+			core.SetBatchSupported(batchSupported)
+
+			lecturerPhase, studentPhase, unknownPhase := uuid.New(), uuid.New(), uuid.New()
+			courseParticipationID := uuid.New()
+			core.SetRoleMapping(lecturerPhase, keycloakTokenVerifierDTO.GetCourseRoles{
+				CourseLecturerRole: "ios25-Lecturer",
+				CustomRolePrefix:   "ios25-cg-",
+			})
+			core.SetRoleMapping(studentPhase, keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios24-Lecturer"})
+			core.AddStudent(lecturerPhase, "other", keycloakTokenVerifierDTO.GetCoursePhaseParticipation{})
+			core.AddStudent(studentPhase, "user", keycloakTokenVerifierDTO.GetCoursePhaseParticipation{
+				IsStudentOfCoursePhase: true,
+				CourseParticipationID:  courseParticipationID,
+			})
+
+			v, err := keycloakTokenVerifier.NewVerifier(kc.URL(), kc.Realm, core.URL())
+			require.NoError(t, err)
+			tokenUser := keycloakTokenVerifier.TokenUser{
+				ID:    "user",
+				Roles: map[string]bool{"ios25-Lecturer": true, "ios25-cg-Tutor": true},
+			}
+			authHeader := kc.Token().Subject("user").BearerHeader()
+
+			access, err := v.ResolveCoursePhaseAccess(context.Background(), authHeader, tokenUser, []uuid.UUID{lecturerPhase, studentPhase, unknownPhase, studentPhase, unknownPhase})
+			require.NoError(t, err)
+
+			require.Len(t, access, 2, "unknown course phases are omitted")
+			assert.Equal(t, keycloakTokenVerifier.CoursePhaseAccess{
+				IsLecturer:       true,
+				CustomRolePrefix: "ios25-cg-",
+				CustomRoles:      []string{"Tutor"},
+			}, access[lecturerPhase])
+			assert.Equal(t, keycloakTokenVerifier.CoursePhaseAccess{
+				IsStudentOfCourse:      true,
+				IsStudentOfCoursePhase: true,
+				CourseParticipationID:  courseParticipationID,
+			}, access[studentPhase])
+
+			enriched := tokenUser.WithCoursePhaseAccess(access[studentPhase])
+			assert.True(t, enriched.IsStudentOfCoursePhase)
+			assert.Equal(t, courseParticipationID, enriched.CourseParticipationID)
+
+			singleRequests := core.RequestCount("/api/auth/course_phase/" + studentPhase.String() + "/roles")
+			if batchSupported {
+				assert.Equal(t, 1, core.RequestCount("/api/auth/course_phases/access"))
+				assert.Zero(t, singleRequests)
+			} else {
+				assert.Equal(t, 1, singleRequests, "duplicate course phases are requested once")
+			}
+			assert.Equal(t, 1, core.RequestCount("/api/auth/course_phase/"+unknownPhase.String()+"/roles"),
+				"course phases missing from the batch response are checked with a single request")
+
+			core.Fail("/api/auth/course_phase/"+unknownPhase.String()+"/roles", http.StatusInternalServerError)
+			access, err = v.ResolveCoursePhaseAccess(context.Background(), authHeader, tokenUser, []uuid.UUID{lecturerPhase, unknownPhase})
+			require.Error(t, err)
+			assert.Nil(t, access, "no partial result with an error")
+		})
+	}
+}
+
+func TestServer_AuthenticationMiddleware_CoursePhaseIDExtractor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+
+	coursePhaseID := uuid.New()
+	teamID := uuid.New()
+	core.SetRoleMapping(coursePhaseID, keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios25-Lecturer"})
+
+	v, err := keycloakTokenVerifier.NewVerifier(kc.URL(), kc.Realm, core.URL(),
+		keycloakTokenVerifier.WithCoursePhaseIDExtractor(keycloakTokenVerifier.CoursePhaseIDFromQuery("coursePhaseID")))
+	require.NoError(t, err)
+
+	var lookups int
+	byTeam := keycloakTokenVerifier.CoursePhaseIDFromResolver("teamID", func(ctx context.Context, entityID string) (uuid.UUID, error) {
+		lookups++
+		if entityID == teamID.String() {
+			return coursePhaseID, nil
+		}
+		return uuid.Nil, nil
+	})
+
+	handler := func(c *gin.Context) {
+		resolved, _ := keycloakTokenVerifier.GetCoursePhaseID(c)
+		c.String(http.StatusOK, resolved.String())
+	}
+	router := gin.New()
+	router.GET("/participations", v.AuthenticationMiddleware(keycloakTokenVerifier.CourseLecturer), handler)
+	router.GET("/teams/:teamID", keycloakTokenVerifier.UseCoursePhaseIDExtractor(byTeam),
+		v.AuthenticationMiddleware(keycloakTokenVerifier.CourseLecturer, keycloakTokenVerifier.CourseStudent), handler)
+
+	lecturer := kc.Token().Subject("lecturer").Roles("ios25-Lecturer").BearerHeader()
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "global query extractor", path: "/participations?coursePhaseID=" + coursePhaseID.String(), wantStatus: http.StatusOK},
+		{name: "global query extractor without query", path: "/participations", wantStatus: http.StatusBadRequest},
+		{name: "route resolver", path: "/teams/" + teamID.String(), wantStatus: http.StatusOK},
+		{name: "route resolver with unknown team", path: "/teams/" + uuid.NewString(), wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", lecturer)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, coursePhaseID.String(), rec.Body.String())
+			}
+		})
+	}
+	assert.Equal(t, 2, lookups, "the resolver is called once per request")
+}
+
+func TestServer_PolicyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+
+	coursePhaseID := uuid.New()
+	core.SetRoleMapping(coursePhaseID, keycloakTokenVerifierDTO.GetCourseRoles{
+		CourseEditorRole: "ios25-Editor",
+		CustomRolePrefix: "ios25-cg-",
+	})
+	core.AddStudent(coursePhaseID, "phase-student", keycloakTokenVerifierDTO.GetCoursePhaseParticipation{IsStudentOfCoursePhase: true})
+	core.AddStudent(coursePhaseID, "course-student", keycloakTokenVerifierDTO.GetCoursePhaseParticipation{IsStudentOfCoursePhase: false})
+
+	v, err := keycloakTokenVerifier.NewVerifier(kc.URL(), kc.Realm, core.URL())
+	require.NoError(t, err)
+
+	isOwner := keycloakTokenVerifier.Predicate(func(tokenUser keycloakTokenVerifier.TokenUser, c *gin.Context) bool {
+		return c.Query("owner") == tokenUser.ID
+	})
+	isStudentOfCoursePhase := keycloakTokenVerifier.Predicate(func(tokenUser keycloakTokenVerifier.TokenUser, _ *gin.Context) bool {
+		return tokenUser.IsStudentOfCoursePhase
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	router := gin.New()
+	router.GET("/:coursePhaseID/owned", v.PolicyMiddleware(keycloakTokenVerifier.All(keycloakTokenVerifier.Role(keycloakTokenVerifier.CourseEditor), isOwner)), ok)
+	router.GET("/:coursePhaseID/phase", v.PolicyMiddleware(keycloakTokenVerifier.All(keycloakTokenVerifier.Role(keycloakTokenVerifier.CourseStudent), isStudentOfCoursePhase)), ok)
+	router.GET("/:coursePhaseID/tutors", v.PolicyMiddleware(keycloakTokenVerifier.Any(keycloakTokenVerifier.Role("Tutor"), keycloakTokenVerifier.Role(keycloakTokenVerifier.PromptLecturer))), ok)
+	router.GET("/:coursePhaseID/non-students", v.PolicyMiddleware(keycloakTokenVerifier.Not(keycloakTokenVerifier.Role(keycloakTokenVerifier.CourseStudent))), ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		token      *keycloakTesting.TokenBuilder
+		wantStatus int
+	}{
+		{name: "editor and owner", path: "/owned?owner=editor", token: kc.Token().Subject("editor").Roles("ios25-Editor"), wantStatus: http.StatusOK},
+		{name: "editor but not owner", path: "/owned?owner=someone", token: kc.Token().Subject("editor").Roles("ios25-Editor"), wantStatus: http.StatusForbidden},
+		{name: "owner but not editor", path: "/owned?owner=other", token: kc.Token().Subject("other"), wantStatus: http.StatusForbidden},
+		{name: "student of course phase", path: "/phase", token: kc.Token().Subject("phase-student"), wantStatus: http.StatusOK},
+		{name: "student of course only", path: "/phase", token: kc.Token().Subject("course-student"), wantStatus: http.StatusForbidden},
+		{name: "custom role", path: "/tutors", token: kc.Token().Subject("tutor").Roles("ios25-cg-Tutor"), wantStatus: http.StatusOK},
+		{name: "prompt lecturer", path: "/tutors", token: kc.Token().Subject("lecturer").Roles(keycloakTokenVerifier.PromptLecturer), wantStatus: http.StatusOK},
+		{name: "neither custom role nor prompt lecturer", path: "/tutors", token: kc.Token().Subject("other"), wantStatus: http.StatusForbidden},
+		{name: "not a student", path: "/non-students", token: kc.Token().Subject("other"), wantStatus: http.StatusOK},
+		{name: "student excluded", path: "/non-students", token: kc.Token().Subject("course-student"), wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/"+coursePhaseID.String()+tt.path, nil)
+			req.Header.Set("Authorization", tt.token.BearerHeader())
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestServer_PolicyMiddleware_ProblemDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+	coursePhaseID := uuid.New()
+	core.SetRoleMapping(coursePhaseID, keycloakTokenVerifierDTO.GetCourseRoles{CourseEditorRole: "ios25-Editor"})
+
+	v, err := keycloakTokenVerifier.NewVerifier(kc.URL(), kc.Realm, core.URL())
+	require.NoError(t, err)
+	router := gin.New()
+	router.GET("/:coursePhaseID", v.PolicyMiddleware(keycloakTokenVerifier.Any(
+		keycloakTokenVerifier.Role(keycloakTokenVerifier.CourseEditor),
+		keycloakTokenVerifier.All(keycloakTokenVerifier.Role(keycloakTokenVerifier.CourseStudent), keycloakTokenVerifier.Role(keycloakTokenVerifier.CourseEditor)),
+	)), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/"+coursePhaseID.String(), nil)
+	req.Header.Set("Authorization", kc.Token().Subject("someone").BearerHeader())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, keycloakTokenVerifier.ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Forbidden",
+		"status": 403,
+		"code": "forbidden",
+		"message": "missing required role",
+		"requiredRoles": ["Editor", "Student"]
+	}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/not-a-uuid", nil)
+	req.Header.Set("Authorization", kc.Token().Subject("someone").BearerHeader())
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var problem keycloakTokenVerifier.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, keycloakTokenVerifier.ProblemInvalidCoursePhaseID, problem.Code)
+}
+
+func TestServer_StudentSelfAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+
+	coursePhaseID := uuid.New()
+	ownParticipationID, otherParticipationID := uuid.New(), uuid.New()
+	ownTeamID, otherTeamID := uuid.NewString(), uuid.NewString()
+	core.SetRoleMapping(coursePhaseID, keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios25-Lecturer", CourseEditorRole: "ios25-Editor"})
+	core.AddStudent(coursePhaseID, "student", keycloakTokenVerifierDTO.GetCoursePhaseParticipation{
+		IsStudentOfCoursePhase: true,
+		CourseParticipationID:  ownParticipationID,
+	})
+
+	v, err := keycloakTokenVerifier.NewVerifier(kc.URL(), kc.Realm, core.URL())
+	require.NoError(t, err)
+	isMember := func(ctx context.Context, tokenUser keycloakTokenVerifier.TokenUser, teamID string) (bool, error) {
+		if teamID == "broken" {
+			return false, errors.New("database unavailable")
+		}
+		return tokenUser.CourseParticipationID == ownParticipationID && teamID == ownTeamID, nil
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	router := gin.New()
+	router.GET("/:coursePhaseID/participations/:participationID", v.PolicyMiddleware(keycloakTokenVerifier.StudentSelfAccess("participationID")), ok)
+	router.GET("/:coursePhaseID/teams/:teamID", v.PolicyMiddleware(keycloakTokenVerifier.StudentTeamAccess("teamID", isMember)), ok)
+
+	student := kc.Token().Subject("student")
+	tests := []struct {
+		name       string
+		path       string
+		token      *keycloakTesting.TokenBuilder
+		wantStatus int
+	}{
+		{name: "own participation", path: "/participations/" + ownParticipationID.String(), token: student, wantStatus: http.StatusOK},
+		{name: "other participation", path: "/participations/" + otherParticipationID.String(), token: student, wantStatus: http.StatusForbidden},
+		{name: "lecturer", path: "/participations/" + otherParticipationID.String(), token: kc.Token().Subject("lecturer").Roles("ios25-Lecturer"), wantStatus: http.StatusOK},
+		{name: "editor", path: "/participations/" + otherParticipationID.String(), token: kc.Token().Subject("editor").Roles("ios25-Editor"), wantStatus: http.StatusOK},
+		{name: "prompt admin", path: "/participations/" + otherParticipationID.String(), token: kc.Token().Subject("admin").Roles(keycloakTokenVerifier.PromptAdmin), wantStatus: http.StatusOK},
+		{name: "not a student", path: "/participations/" + ownParticipationID.String(), token: kc.Token().Subject("someone"), wantStatus: http.StatusForbidden},
+		{name: "own team", path: "/teams/" + ownTeamID, token: student, wantStatus: http.StatusOK},
+		{name: "other team", path: "/teams/" + otherTeamID, token: student, wantStatus: http.StatusForbidden},
+		{name: "lecturer of other team", path: "/teams/" + otherTeamID, token: kc.Token().Subject("lecturer").Roles("ios25-Lecturer"), wantStatus: http.StatusOK},
+		{name: "failing membership check", path: "/teams/broken", token: student, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/"+coursePhaseID.String()+tt.path, nil)
+			req.Header.Set("Authorization", tt.token.BearerHeader())
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
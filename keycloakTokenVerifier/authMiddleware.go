@@ -5,7 +5,6 @@ import (
 	"slices"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // AuthenticationMiddleware creates a composite middleware which always
@@ -15,96 +14,99 @@ import (
 //     (any value other than "Admin" or "Student"), then it calls GetLecturerAndEditorRole.
 //     For custom roles the middleware checks if the user's roles include customRolePrefix+customRole.
 //   - If allowedRoles contains "Student", then it calls IsStudentOfCoursePhaseMiddleware.
-func AuthenticationMiddleware(allowedRoles ...string) gin.HandlerFunc {
+func (v *Verifier) AuthenticationMiddleware(allowedRoles ...string) gin.HandlerFunc {
+	allowedSet := buildAllowedRolesSet(allowedRoles)
 	return func(c *gin.Context) {
-		// Always run Keycloak middleware first.
-		KeycloakMiddleware()(c)
+		v.authenticate(c, allowedRoles, allowedSet)
+	}
+}
+
+func (v *Verifier) authenticate(c *gin.Context, allowedRoles []string, allowedSet map[string]struct{}) {
+	// Always run Keycloak middleware first.
+	v.keycloakMiddleware(c)
+	if c.IsAborted() {
+		return
+	}
+
+	tokenUser, ok := GetTokenUser(c)
+	if !ok {
+		v.logger.Error("Error getting token student")
+		abortWithProblem(c, http.StatusInternalServerError, ProblemInternal, ErrUserNotInContext.Error())
+		return
+	}
+	userRoles := tokenUser.Roles
+
+	// 1.) Directly grant access for PROMPT_Admin or PROMPT_Lecturer.
+	if checkDirectRole(PromptAdmin, allowedSet, userRoles) ||
+		checkDirectRole(PromptLecturer, allowedSet, userRoles) {
+		c.Next()
+		return
+	}
+
+	// This allows to use the middleware without coursePhaseID, if only PROMPT_Admin & PROMPT_Lecturer are allowed.
+	if onlyContainsAdminAndLecturer(allowedSet) {
+		abortForbidden(c, allowedRoles)
+		return
+	}
+
+	// 2.) Check for Lecturer, Editor, or custom group roles.
+	if requiresLecturerOrCustom(allowedSet, allowedRoles) {
+		v.getLecturerAndEditorRole(c)
 		if c.IsAborted() {
 			return
 		}
 
-		allowedSet := buildAllowedRolesSet(allowedRoles)
-
-		tokenUser, ok := GetTokenUser(c)
+		tokenUser, ok = GetTokenUser(c)
 		if !ok {
-			log.Error("Error getting token student")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrUserNotInContext)
+			v.logger.Error("Error refreshing the token student")
+			abortWithProblem(c, http.StatusInternalServerError, ProblemInternal, ErrUserNotInContext.Error())
 			return
 		}
-		userRoles := tokenUser.Roles
 
-		// 1.) Directly grant access for PROMPT_Admin or PROMPT_Lecturer.
-		if checkDirectRole(PromptAdmin, allowedSet, userRoles) ||
-			checkDirectRole(PromptLecturer, allowedSet, userRoles) {
+		if _, allowed := allowedSet[CourseLecturer]; allowed && tokenUser.IsLecturer {
 			c.Next()
 			return
 		}
 
-		// This allows to use the middleware without coursePhaseID, if only PROMPT_Admin & PROMPT_Lecturer are allowed.
-		if onlyContainsAdminAndLecturer(allowedSet) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "could not authenticate"})
+		if _, allowed := allowedSet[CourseEditor]; allowed && tokenUser.IsEditor {
+			c.Next()
 			return
 		}
 
-		// 2.) Check for Lecturer, Editor, or custom group roles.
-		if requiresLecturerOrCustom(allowedSet, allowedRoles) {
-			getLecturerAndEditorRole()(c)
-			if c.IsAborted() {
-				return
-			}
-
-			tokenUser, ok = GetTokenUser(c)
-			if !ok {
-				log.Error("Error refreshing the token student")
-				c.AbortWithStatusJSON(http.StatusUnauthorized, ErrUserNotInContext)
-				return
-			}
-
-			if _, allowed := allowedSet[CourseLecturer]; allowed && tokenUser.IsLecturer {
-				c.Next()
-				return
-			}
+		if containsCustomRoleName(allowedRoles...) {
+			prefix := tokenUser.CustomRolePrefix
 
-			if _, allowed := allowedSet[CourseEditor]; allowed && tokenUser.IsEditor {
-				c.Next()
-				return
-			}
-
-			if containsCustomRoleName(allowedRoles...) {
-				prefix := tokenUser.CustomRolePrefix
-
-				for _, role := range allowedRoles {
-					if userRoles[prefix+role] {
-						c.Next()
-						return
-					}
+			for _, role := range allowedRoles {
+				if userRoles[prefix+role] {
+					c.Next()
+					return
 				}
 			}
 		}
+	}
 
-		// 3.) Check for Student.
-		if _, allowed := allowedSet[CourseStudent]; allowed {
-			isStudentOfCoursePhaseMiddleware()(c)
-			if c.IsAborted() {
-				return
-			}
-
-			tokenUser, ok = GetTokenUser(c)
-			if !ok {
-				log.Error("Error refreshing the token student")
-				c.AbortWithStatusJSON(http.StatusUnauthorized, ErrUserNotInContext)
-				return
-			}
+	// 3.) Check for Student.
+	if _, allowed := allowedSet[CourseStudent]; allowed {
+		v.isStudentOfCoursePhase(c)
+		if c.IsAborted() {
+			return
+		}
 
-			if tokenUser.IsStudentOfCourse {
-				c.Next()
-				return
-			}
+		tokenUser, ok = GetTokenUser(c)
+		if !ok {
+			v.logger.Error("Error refreshing the token student")
+			abortWithProblem(c, http.StatusInternalServerError, ProblemInternal, ErrUserNotInContext.Error())
+			return
 		}
 
-		// Access denied.
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "could not authenticate"})
+		if tokenUser.IsStudentOfCourse {
+			c.Next()
+			return
+		}
 	}
+
+	// Access denied: the user is authenticated but has none of the allowed roles.
+	abortForbidden(c, allowedRoles)
 }
 
 // buildAllowedRolesSet creates a lookup set from a slice of roles.
@@ -0,0 +1,35 @@
+package keycloakTokenVerifier
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+// abortWithCoreError aborts the request after a failed core request. The status of the core is not passed on,
+// so that a 401 of the core never logs out a user whose token is valid:
+//   - 401 and 403 of the core mean the user has no access to the course phase and are answered with 403,
+//   - 404 (e.g. an unknown course phase) is answered with 404,
+//   - other responses and unreachable cores are answered with 502,
+//   - everything else is a 500.
+//
+// The error, including the UpstreamError, is only attached to the context for logging middlewares.
+func abortWithCoreError(c *gin.Context, err error) {
+	_ = c.Error(err)
+
+	var upstreamErr *utils.UpstreamError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &upstreamErr) && (upstreamErr.StatusCode == http.StatusUnauthorized || upstreamErr.StatusCode == http.StatusForbidden):
+		abortWithProblem(c, http.StatusForbidden, ProblemForbidden, "no access to the course phase")
+	case errors.As(err, &upstreamErr) && upstreamErr.StatusCode == http.StatusNotFound:
+		abortWithProblem(c, http.StatusNotFound, ProblemCoreRequestFailed, "course phase not found")
+	case errors.As(err, &upstreamErr), errors.As(err, &urlErr):
+		abortWithProblem(c, http.StatusBadGateway, ProblemCoreRequestFailed, "could not resolve course phase roles")
+	default:
+		abortWithProblem(c, http.StatusInternalServerError, ProblemCoreRequestFailed, "could not resolve course phase roles")
+	}
+}
@@ -0,0 +1,202 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakCoreRequests"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+// coursePhaseAccessConcurrency limits the parallel single requests if the core does not support batching.
+const coursePhaseAccessConcurrency = 4
+
+// CoursePhaseAccess is the lecturer, editor, custom and student status of a user in one course phase.
+type CoursePhaseAccess struct {
+	IsLecturer       bool
+	IsEditor         bool
+	CustomRolePrefix string
+	// CustomRoles are the custom roles of the user in the course phase, without CustomRolePrefix.
+	CustomRoles []string
+
+	IsStudentOfCourse      bool
+	IsStudentOfCoursePhase bool
+	CourseParticipationID  uuid.UUID
+}
+
+func newCoursePhaseAccess(userRoles map[string]bool, roles keycloakTokenVerifierDTO.GetCourseRoles, student StudentCacheEntry) CoursePhaseAccess {
+	access := CoursePhaseAccess{
+		IsLecturer:        roles.CourseLecturerRole != "" && userRoles[roles.CourseLecturerRole],
+		IsEditor:          roles.CourseEditorRole != "" && userRoles[roles.CourseEditorRole],
+		CustomRolePrefix:  roles.CustomRolePrefix,
+		IsStudentOfCourse: student.IsStudentOfCourse,
+	}
+	if roles.CustomRolePrefix != "" {
+		for role, granted := range userRoles {
+			if customRole, ok := strings.CutPrefix(role, roles.CustomRolePrefix); ok && granted {
+				access.CustomRoles = append(access.CustomRoles, customRole)
+			}
+		}
+	}
+	if student.IsStudentOfCourse {
+		access.IsStudentOfCoursePhase = student.Participation.IsStudentOfCoursePhase
+		access.CourseParticipationID = student.Participation.CourseParticipationID
+	}
+	return access
+}
+
+// WithCoursePhaseAccess returns a copy of the token user enriched with the access of one course phase,
+// as the AuthenticationMiddleware would have set it for a route of that course phase.
+func (t TokenUser) WithCoursePhaseAccess(access CoursePhaseAccess) TokenUser {
+	t.IsLecturer = access.IsLecturer
+	t.IsEditor = access.IsEditor
+	t.CustomRolePrefix = access.CustomRolePrefix
+	t.IsStudentOfCourse = access.IsStudentOfCourse
+	t.IsStudentOfCoursePhase = access.IsStudentOfCoursePhase
+	t.CourseParticipationID = access.CourseParticipationID
+	return t
+}
+
+// ResolveCoursePhaseAccess is Verifier.ResolveCoursePhaseAccess of the default verifier.
+func ResolveCoursePhaseAccess(ctx context.Context, authHeader string, tokenUser TokenUser, coursePhaseIDs []uuid.UUID) (map[uuid.UUID]CoursePhaseAccess, error) {
+	v := defaultVerifier.Load()
+	if v == nil {
+		return nil, ErrNotInitialized
+	}
+	return v.ResolveCoursePhaseAccess(ctx, authHeader, tokenUser, coursePhaseIDs)
+}
+
+// ResolveCoursePhaseAccess resolves the access of the user to several course phases at once,
+// e.g. for a course overview. Course phases unknown to the core are missing from the result.
+// It uses the batch endpoint of the core and falls back to parallel single requests if the core does not support it.
+// Requested course phases missing from the batch response are checked with single requests, and course phases
+// that were not requested are ignored. Cached entries of the configured CoursePhaseCache are used and new results
+// are cached. If any course phase cannot be resolved, only the error is returned.
+func (v *Verifier) ResolveCoursePhaseAccess(ctx context.Context, authHeader string, tokenUser TokenUser, coursePhaseIDs []uuid.UUID) (map[uuid.UUID]CoursePhaseAccess, error) {
+	result := make(map[uuid.UUID]CoursePhaseAccess, len(coursePhaseIDs))
+	cache := v.getCoursePhaseCache()
+
+	var missing []uuid.UUID
+	requested := make(map[uuid.UUID]bool, len(coursePhaseIDs))
+	for _, coursePhaseID := range coursePhaseIDs {
+		if requested[coursePhaseID] {
+			continue
+		}
+		requested[coursePhaseID] = true
+		if cache != nil {
+			key := CoursePhaseCacheKey{CoursePhaseID: coursePhaseID, Subject: tokenUser.ID}
+			roles, rolesCached := cache.GetRoleMapping(key)
+			student, studentCached := cache.GetStudent(key)
+			if rolesCached && studentCached {
+				result[coursePhaseID] = newCoursePhaseAccess(tokenUser.Roles, roles, student)
+				continue
+			}
+		}
+		missing = append(missing, coursePhaseID)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	batch, err := v.core.SendCoursePhasesAccessRequest(ctx, authHeader, missing)
+	if errors.Is(err, keycloakCoreRequests.ErrBatchNotSupported) {
+		if err := v.resolveCoursePhaseAccessSingly(ctx, authHeader, tokenUser, missing, result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var notInBatch []uuid.UUID
+	for _, coursePhaseID := range missing {
+		entry, ok := batch[coursePhaseID]
+		if !ok {
+			notInBatch = append(notInBatch, coursePhaseID)
+			continue
+		}
+		student := StudentCacheEntry{IsStudentOfCourse: entry.IsStudentOfCourse}
+		if entry.IsStudentOfCourse {
+			student.Participation = entry.Participation
+		}
+		if cache != nil {
+			key := CoursePhaseCacheKey{CoursePhaseID: coursePhaseID, Subject: tokenUser.ID}
+			cache.SetRoleMapping(key, entry.Roles)
+			cache.SetStudent(key, student)
+		}
+		result[coursePhaseID] = newCoursePhaseAccess(tokenUser.Roles, entry.Roles, student)
+	}
+	if len(notInBatch) > 0 {
+		if err := v.resolveCoursePhaseAccessSingly(ctx, authHeader, tokenUser, notInBatch, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// resolveCoursePhaseAccessSingly requests the role mapping and the student status of each course phase
+// with the single endpoints in parallel and adds them to result. Once ctx is done, no further lookups are started.
+func (v *Verifier) resolveCoursePhaseAccessSingly(ctx context.Context, authHeader string, tokenUser TokenUser, coursePhaseIDs []uuid.UUID, result map[uuid.UUID]CoursePhaseAccess) error {
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, coursePhaseAccessConcurrency)
+	)
+lookups:
+	for _, coursePhaseID := range coursePhaseIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs = append(errs, ctx.Err())
+			mu.Unlock()
+			break lookups
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			access, found, err := v.getCoursePhaseAccess(ctx, authHeader, tokenUser, coursePhaseID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				errs = append(errs, err)
+			case found:
+				result[coursePhaseID] = access
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// getCoursePhaseAccess resolves the access of one course phase. found is false if the core does not know the course phase.
+func (v *Verifier) getCoursePhaseAccess(ctx context.Context, authHeader string, tokenUser TokenUser, coursePhaseID uuid.UUID) (access CoursePhaseAccess, found bool, err error) {
+	roles, err := v.getCoursePhaseRoleMapping(ctx, authHeader, coursePhaseID, tokenUser.ID)
+	if err != nil {
+		return CoursePhaseAccess{}, false, ignoreNotFound(err)
+	}
+	student, err := v.getStudentOfCoursePhase(ctx, authHeader, coursePhaseID, tokenUser.ID)
+	if err != nil {
+		return CoursePhaseAccess{}, false, ignoreNotFound(err)
+	}
+	return newCoursePhaseAccess(tokenUser.Roles, roles, student), true, nil
+}
+
+func ignoreNotFound(err error) error {
+	var upstreamErr *utils.UpstreamError
+	if errors.As(err, &upstreamErr) && upstreamErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+)
+
+func TestVerifier_ResolveCoursePhaseAccess_IgnoresUnrequestedCoursePhases(t *testing.T) {
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	requestedPhase, unrequestedPhase := uuid.New(), uuid.New()
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[uuid.UUID]keycloakTokenVerifierDTO.GetCoursePhaseAccess{
+			requestedPhase:   {Roles: keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios25-Lecturer"}},
+			unrequestedPhase: {Roles: keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios24-Lecturer"}},
+		})
+	}))
+	t.Cleanup(core.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, core.URL)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+
+	tokenUser := TokenUser{ID: "user", Roles: map[string]bool{"ios25-Lecturer": true, "ios24-Lecturer": true}}
+	access, err := v.ResolveCoursePhaseAccess(context.Background(), "Bearer token", tokenUser, []uuid.UUID{requestedPhase})
+	if err != nil {
+		t.Fatalf("ResolveCoursePhaseAccess() error = %v", err)
+	}
+	if len(access) != 1 || !access[requestedPhase].IsLecturer {
+		t.Errorf("access = %+v; want only the requested course phase", access)
+	}
+}
+
+func TestVerifier_ResolveCoursePhaseAccess_StopsWhenContextIsDone(t *testing.T) {
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var roleRequests atomic.Int32
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/roles") {
+			roleRequests.Add(1)
+			cancel()
+		}
+		// no batch endpoint, so every course phase is looked up singly
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(core.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, core.URL)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+
+	coursePhaseIDs := make([]uuid.UUID, 20)
+	for i := range coursePhaseIDs {
+		coursePhaseIDs[i] = uuid.New()
+	}
+	_, err = v.ResolveCoursePhaseAccess(ctx, "Bearer token", TokenUser{ID: "user"}, coursePhaseIDs)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ResolveCoursePhaseAccess() error = %v; want %v", err, context.Canceled)
+	}
+	if n := roleRequests.Load(); n > coursePhaseAccessConcurrency {
+		t.Errorf("role requests = %d; want at most %d after cancellation", n, coursePhaseAccessConcurrency)
+	}
+}
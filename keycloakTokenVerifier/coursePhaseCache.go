@@ -0,0 +1,233 @@
+package keycloakTokenVerifier
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+)
+
+// CoursePhaseCacheKey identifies a cached course phase lookup of a single user.
+type CoursePhaseCacheKey struct {
+	CoursePhaseID uuid.UUID
+	Subject       string
+}
+
+// StudentCacheEntry is the cached result of an is_student request.
+// IsStudentOfCourse = false is used for negative caching ("not student of course").
+type StudentCacheEntry struct {
+	IsStudentOfCourse bool
+	Participation     keycloakTokenVerifierDTO.GetCoursePhaseParticipation
+}
+
+// CoursePhaseCache caches the course phase role mappings and student checks requested from the core.
+// Implementations must be safe for concurrent use.
+type CoursePhaseCache interface {
+	GetRoleMapping(key CoursePhaseCacheKey) (keycloakTokenVerifierDTO.GetCourseRoles, bool)
+	SetRoleMapping(key CoursePhaseCacheKey, roles keycloakTokenVerifierDTO.GetCourseRoles)
+
+	GetStudent(key CoursePhaseCacheKey) (StudentCacheEntry, bool)
+	SetStudent(key CoursePhaseCacheKey, entry StudentCacheEntry)
+
+	// Invalidate removes all cached entries of a single user in a course phase.
+	Invalidate(key CoursePhaseCacheKey)
+	// InvalidateCoursePhase removes all cached entries of a course phase.
+	InvalidateCoursePhase(coursePhaseID uuid.UUID)
+	// Clear removes all cached entries.
+	Clear()
+}
+
+// coursePhaseCache is used by the default verifier if it is not configured with WithCoursePhaseCache.
+// It is nil by default, which disables caching.
+var (
+	coursePhaseCache   CoursePhaseCache
+	coursePhaseCacheMu sync.RWMutex
+)
+
+// SetCoursePhaseCache configures the cache used by the default verifier.
+// Passing nil disables caching.
+func SetCoursePhaseCache(cache CoursePhaseCache) {
+	coursePhaseCacheMu.Lock()
+	coursePhaseCache = cache
+	coursePhaseCacheMu.Unlock()
+
+	if v := defaultVerifier.Load(); v != nil {
+		v.setCoursePhaseCache(cache)
+	}
+}
+
+func getCoursePhaseCache() CoursePhaseCache {
+	coursePhaseCacheMu.RLock()
+	defer coursePhaseCacheMu.RUnlock()
+	return coursePhaseCache
+}
+
+// InvalidateCoursePhaseCache removes the cached entries of a user in a course phase of the default verifier.
+// It is a no-op if no cache is configured.
+func InvalidateCoursePhaseCache(coursePhaseID uuid.UUID, subject string) {
+	if v := defaultVerifier.Load(); v != nil {
+		v.InvalidateCoursePhaseCache(coursePhaseID, subject)
+	}
+}
+
+// InvalidateCoursePhaseCacheForPhase removes the cached entries of all users in a course phase of the default verifier,
+// e.g. after the role mapping or the participations of the phase changed.
+func InvalidateCoursePhaseCacheForPhase(coursePhaseID uuid.UUID) {
+	if v := defaultVerifier.Load(); v != nil {
+		v.InvalidateCoursePhaseCacheForPhase(coursePhaseID)
+	}
+}
+
+// SetCoursePhaseCache replaces the cache of the verifier. Passing nil disables caching.
+func (v *Verifier) SetCoursePhaseCache(cache CoursePhaseCache) {
+	v.setCoursePhaseCache(cache)
+}
+
+func (v *Verifier) setCoursePhaseCache(cache CoursePhaseCache) {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+	v.cache = cache
+}
+
+func (v *Verifier) getCoursePhaseCache() CoursePhaseCache {
+	v.cacheMu.RLock()
+	defer v.cacheMu.RUnlock()
+	return v.cache
+}
+
+// InvalidateCoursePhaseCache removes the cached entries of a user in a course phase.
+// It is a no-op if no cache is configured.
+func (v *Verifier) InvalidateCoursePhaseCache(coursePhaseID uuid.UUID, subject string) {
+	if cache := v.getCoursePhaseCache(); cache != nil {
+		cache.Invalidate(CoursePhaseCacheKey{CoursePhaseID: coursePhaseID, Subject: subject})
+	}
+}
+
+// InvalidateCoursePhaseCacheForPhase removes the cached entries of all users in a course phase.
+func (v *Verifier) InvalidateCoursePhaseCacheForPhase(coursePhaseID uuid.UUID) {
+	if cache := v.getCoursePhaseCache(); cache != nil {
+		cache.InvalidateCoursePhase(coursePhaseID)
+	}
+}
+
+type ttlEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// TTLCoursePhaseCache is an in-memory CoursePhaseCache whose entries expire after a fixed TTL.
+type TTLCoursePhaseCache struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu           sync.Mutex
+	roleMappings map[CoursePhaseCacheKey]ttlEntry[keycloakTokenVerifierDTO.GetCourseRoles]
+	students     map[CoursePhaseCacheKey]ttlEntry[StudentCacheEntry]
+	lastSweep    time.Time
+}
+
+// NewTTLCoursePhaseCache creates an in-memory cache. Positive results are kept for ttl,
+// "not student of course" results for negativeTTL. A negativeTTL <= 0 falls back to ttl.
+func NewTTLCoursePhaseCache(ttl, negativeTTL time.Duration) *TTLCoursePhaseCache {
+	if negativeTTL <= 0 {
+		negativeTTL = ttl
+	}
+	return &TTLCoursePhaseCache{
+		ttl:          ttl,
+		negativeTTL:  negativeTTL,
+		now:          time.Now,
+		roleMappings: make(map[CoursePhaseCacheKey]ttlEntry[keycloakTokenVerifierDTO.GetCourseRoles]),
+		students:     make(map[CoursePhaseCacheKey]ttlEntry[StudentCacheEntry]),
+	}
+}
+
+func (t *TTLCoursePhaseCache) GetRoleMapping(key CoursePhaseCacheKey) (keycloakTokenVerifierDTO.GetCourseRoles, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return getUnexpired(t.roleMappings, key, t.now())
+}
+
+func (t *TTLCoursePhaseCache) SetRoleMapping(key CoursePhaseCacheKey, roles keycloakTokenVerifierDTO.GetCourseRoles) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.roleMappings[key] = ttlEntry[keycloakTokenVerifierDTO.GetCourseRoles]{value: roles, expiresAt: now.Add(t.ttl)}
+	t.sweep(now)
+}
+
+func (t *TTLCoursePhaseCache) GetStudent(key CoursePhaseCacheKey) (StudentCacheEntry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return getUnexpired(t.students, key, t.now())
+}
+
+func (t *TTLCoursePhaseCache) SetStudent(key CoursePhaseCacheKey, entry StudentCacheEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	ttl := t.ttl
+	if !entry.IsStudentOfCourse {
+		ttl = t.negativeTTL
+	}
+	t.students[key] = ttlEntry[StudentCacheEntry]{value: entry, expiresAt: now.Add(ttl)}
+	t.sweep(now)
+}
+
+func (t *TTLCoursePhaseCache) Invalidate(key CoursePhaseCacheKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.roleMappings, key)
+	delete(t.students, key)
+}
+
+func (t *TTLCoursePhaseCache) InvalidateCoursePhase(coursePhaseID uuid.UUID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.roleMappings {
+		if key.CoursePhaseID == coursePhaseID {
+			delete(t.roleMappings, key)
+		}
+	}
+	for key := range t.students {
+		if key.CoursePhaseID == coursePhaseID {
+			delete(t.students, key)
+		}
+	}
+}
+
+func (t *TTLCoursePhaseCache) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clear(t.roleMappings)
+	clear(t.students)
+}
+
+// sweep removes expired entries at most once per TTL so that the maps do not grow unbounded.
+// The caller must hold t.mu.
+func (t *TTLCoursePhaseCache) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < min(t.ttl, t.negativeTTL) {
+		return
+	}
+	t.lastSweep = now
+	deleteExpired(t.roleMappings, now)
+	deleteExpired(t.students, now)
+}
+
+func getUnexpired[T any](entries map[CoursePhaseCacheKey]ttlEntry[T], key CoursePhaseCacheKey, now time.Time) (T, bool) {
+	entry, ok := entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		var zero T
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func deleteExpired[T any](entries map[CoursePhaseCacheKey]ttlEntry[T], now time.Time) {
+	for key, entry := range entries {
+		if !now.Before(entry.expiresAt) {
+			delete(entries, key)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package keycloakTokenVerifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+)
+
+func newTestCache(ttl, negativeTTL time.Duration) (*TTLCoursePhaseCache, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewTTLCoursePhaseCache(ttl, negativeTTL)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestTTLCoursePhaseCache_RoleMappingExpires(t *testing.T) {
+	cache, now := newTestCache(time.Minute, 0)
+	key := CoursePhaseCacheKey{CoursePhaseID: uuid.New(), Subject: "user"}
+	roles := keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "lec", CourseEditorRole: "edit"}
+
+	cache.SetRoleMapping(key, roles)
+	got, ok := cache.GetRoleMapping(key)
+	if !ok || got != roles {
+		t.Fatalf("expected cached roles %v, got %v (ok=%v)", roles, got, ok)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, ok := cache.GetRoleMapping(key); ok {
+		t.Errorf("expected role mapping to be expired")
+	}
+}
+
+func TestTTLCoursePhaseCache_NegativeTTL(t *testing.T) {
+	cache, now := newTestCache(time.Minute, 10*time.Second)
+	student := CoursePhaseCacheKey{CoursePhaseID: uuid.New(), Subject: "student"}
+	other := CoursePhaseCacheKey{CoursePhaseID: student.CoursePhaseID, Subject: "other"}
+
+	cache.SetStudent(student, StudentCacheEntry{IsStudentOfCourse: true})
+	cache.SetStudent(other, StudentCacheEntry{IsStudentOfCourse: false})
+
+	*now = now.Add(10 * time.Second)
+	if _, ok := cache.GetStudent(other); ok {
+		t.Errorf("expected negative entry to be expired")
+	}
+	if entry, ok := cache.GetStudent(student); !ok || !entry.IsStudentOfCourse {
+		t.Errorf("expected positive entry to be cached, got %v (ok=%v)", entry, ok)
+	}
+}
+
+func TestTTLCoursePhaseCache_Invalidate(t *testing.T) {
+	cache, _ := newTestCache(time.Minute, 0)
+	phase := uuid.New()
+	a := CoursePhaseCacheKey{CoursePhaseID: phase, Subject: "a"}
+	b := CoursePhaseCacheKey{CoursePhaseID: phase, Subject: "b"}
+	c := CoursePhaseCacheKey{CoursePhaseID: uuid.New(), Subject: "a"}
+	for _, key := range []CoursePhaseCacheKey{a, b, c} {
+		cache.SetRoleMapping(key, keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "lec"})
+		cache.SetStudent(key, StudentCacheEntry{IsStudentOfCourse: true})
+	}
+
+	cache.Invalidate(a)
+	if _, ok := cache.GetRoleMapping(a); ok {
+		t.Errorf("expected role mapping of %v to be invalidated", a)
+	}
+	if _, ok := cache.GetStudent(b); !ok {
+		t.Errorf("expected student entry of %v to be kept", b)
+	}
+
+	cache.InvalidateCoursePhase(phase)
+	if _, ok := cache.GetStudent(b); ok {
+		t.Errorf("expected student entry of %v to be invalidated", b)
+	}
+	if _, ok := cache.GetStudent(c); !ok {
+		t.Errorf("expected entries of other course phases to be kept")
+	}
+
+	cache.Clear()
+	if _, ok := cache.GetRoleMapping(c); ok {
+		t.Errorf("expected cache to be empty")
+	}
+}
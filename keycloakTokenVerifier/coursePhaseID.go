@@ -0,0 +1,135 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	coursePhaseIDExtractorContextKey = "coursePhaseIDExtractor"
+	coursePhaseIDContextKey          = "resolvedCoursePhaseID"
+)
+
+// ErrInvalidCoursePhaseID is returned by the built-in extractors if the course phase ID is missing or not a UUID.
+var ErrInvalidCoursePhaseID = errors.New("invalid coursePhaseID")
+
+// CoursePhaseIDExtractor returns the ID of the course phase a request refers to.
+// The AuthenticationMiddleware responds with 400 to errors wrapping ErrInvalidCoursePhaseID.
+// Other errors are treated like failed core requests.
+type CoursePhaseIDExtractor func(c *gin.Context) (uuid.UUID, error)
+
+// CoursePhaseIDFromParam reads the course phase ID from a route parameter. The default is CoursePhaseIDFromParam("coursePhaseID").
+func CoursePhaseIDFromParam(name string) CoursePhaseIDExtractor {
+	return func(c *gin.Context) (uuid.UUID, error) {
+		return parseCoursePhaseID(c.Param(name), "route parameter "+name)
+	}
+}
+
+// CoursePhaseIDFromQuery reads the course phase ID from a query parameter.
+func CoursePhaseIDFromQuery(name string) CoursePhaseIDExtractor {
+	return func(c *gin.Context) (uuid.UUID, error) {
+		return parseCoursePhaseID(c.Query(name), "query parameter "+name)
+	}
+}
+
+// CoursePhaseIDFromHeader reads the course phase ID from a request header.
+func CoursePhaseIDFromHeader(name string) CoursePhaseIDExtractor {
+	return func(c *gin.Context) (uuid.UUID, error) {
+		return parseCoursePhaseID(c.GetHeader(name), "header "+name)
+	}
+}
+
+// CoursePhaseIDFromResolver looks up the course phase of another entity, e.g. of a team,
+// whose ID is read from the route parameter entityParam:
+//
+//	CoursePhaseIDFromResolver("teamID", func(ctx context.Context, teamID string) (uuid.UUID, error) {
+//		return queries.GetCoursePhaseIDOfTeam(ctx, uuid.MustParse(teamID))
+//	})
+//
+// resolve is called with the context of the request and at most once per request.
+func CoursePhaseIDFromResolver(entityParam string, resolve func(ctx context.Context, entityID string) (uuid.UUID, error)) CoursePhaseIDExtractor {
+	return func(c *gin.Context) (uuid.UUID, error) {
+		entityID := c.Param(entityParam)
+		if entityID == "" {
+			return uuid.Nil, fmt.Errorf("%w: route parameter %s missing", ErrInvalidCoursePhaseID, entityParam)
+		}
+		coursePhaseID, err := resolve(c.Request.Context(), entityID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if coursePhaseID == uuid.Nil {
+			return uuid.Nil, fmt.Errorf("%w: no course phase for %s %s", ErrInvalidCoursePhaseID, entityParam, entityID)
+		}
+		return coursePhaseID, nil
+	}
+}
+
+// UseCoursePhaseIDExtractor returns a handler that makes the AuthenticationMiddleware of the following handlers
+// use extractor instead of the one configured with WithCoursePhaseIDExtractor, e.g. for a single route or group:
+//
+//	router.GET("/teams/:teamID", UseCoursePhaseIDExtractor(byTeam), AuthenticationMiddleware(CourseLecturer), handler)
+func UseCoursePhaseIDExtractor(extractor CoursePhaseIDExtractor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(coursePhaseIDExtractorContextKey, extractor)
+	}
+}
+
+// GetCoursePhaseID returns the course phase ID the AuthenticationMiddleware resolved for the request.
+func GetCoursePhaseID(c *gin.Context) (uuid.UUID, bool) {
+	if coursePhaseID, exists := c.Get(coursePhaseIDContextKey); exists {
+		id, ok := coursePhaseID.(uuid.UUID)
+		return id, ok
+	}
+	return uuid.Nil, false
+}
+
+// coursePhaseID extracts the course phase ID of the request once and remembers it in the context.
+func (v *Verifier) coursePhaseID(c *gin.Context) (uuid.UUID, error) {
+	if coursePhaseID, ok := GetCoursePhaseID(c); ok {
+		return coursePhaseID, nil
+	}
+
+	extractor := v.config.coursePhaseIDExtractor
+	if routeExtractor, exists := c.Get(coursePhaseIDExtractorContextKey); exists {
+		if e, ok := routeExtractor.(CoursePhaseIDExtractor); ok {
+			extractor = e
+		}
+	}
+
+	coursePhaseID, err := extractor(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	c.Set(coursePhaseIDContextKey, coursePhaseID)
+	return coursePhaseID, nil
+}
+
+// abortWithCoursePhaseIDError aborts the request if the course phase ID could not be extracted.
+func (v *Verifier) abortWithCoursePhaseIDError(c *gin.Context, err error) {
+	v.logger.Error("Error getting coursePhaseID: ", err)
+	if errors.Is(err, ErrInvalidCoursePhaseID) {
+		_ = c.Error(err)
+		abortWithProblem(c, http.StatusBadRequest, ProblemInvalidCoursePhaseID, err.Error())
+		return
+	}
+	abortWithCoreError(c, err)
+}
+
+func parseCoursePhaseID(value, source string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, fmt.Errorf("%w: %s missing", ErrInvalidCoursePhaseID, source)
+	}
+	coursePhaseID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s: %w", ErrInvalidCoursePhaseID, source, err)
+	}
+	if coursePhaseID == uuid.Nil {
+		return uuid.Nil, fmt.Errorf("%w: %s missing", ErrInvalidCoursePhaseID, source)
+	}
+	return coursePhaseID, nil
+}
@@ -0,0 +1,108 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestCoursePhaseIDExtractors(t *testing.T) {
+	coursePhaseID := uuid.New()
+	teamID := uuid.New()
+	errLookup := errors.New("lookup failed")
+	byTeam := CoursePhaseIDFromResolver("teamID", func(ctx context.Context, entityID string) (uuid.UUID, error) {
+		switch entityID {
+		case teamID.String():
+			return coursePhaseID, nil
+		case "broken":
+			return uuid.Nil, errLookup
+		default:
+			return uuid.Nil, nil
+		}
+	})
+
+	tests := []struct {
+		name      string
+		extractor CoursePhaseIDExtractor
+		params    gin.Params
+		url       string
+		header    http.Header
+		wantErr   error
+	}{
+		{
+			name:      "route parameter",
+			extractor: CoursePhaseIDFromParam("coursePhaseID"),
+			params:    gin.Params{{Key: "coursePhaseID", Value: coursePhaseID.String()}},
+		},
+		{
+			name:      "missing route parameter",
+			extractor: CoursePhaseIDFromParam("coursePhaseID"),
+			wantErr:   ErrInvalidCoursePhaseID,
+		},
+		{
+			name:      "query parameter",
+			extractor: CoursePhaseIDFromQuery("phase"),
+			url:       "/?phase=" + coursePhaseID.String(),
+		},
+		{
+			name:      "invalid query parameter",
+			extractor: CoursePhaseIDFromQuery("phase"),
+			url:       "/?phase=abc",
+			wantErr:   ErrInvalidCoursePhaseID,
+		},
+		{
+			name:      "header",
+			extractor: CoursePhaseIDFromHeader("X-Course-Phase-ID"),
+			header:    http.Header{"X-Course-Phase-Id": {coursePhaseID.String()}},
+		},
+		{
+			name:      "resolver",
+			extractor: byTeam,
+			params:    gin.Params{{Key: "teamID", Value: teamID.String()}},
+		},
+		{
+			name:      "resolver without course phase",
+			extractor: byTeam,
+			params:    gin.Params{{Key: "teamID", Value: uuid.NewString()}},
+			wantErr:   ErrInvalidCoursePhaseID,
+		},
+		{
+			name:      "failing resolver",
+			extractor: byTeam,
+			params:    gin.Params{{Key: "teamID", Value: "broken"}},
+			wantErr:   errLookup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			url := tt.url
+			if url == "" {
+				url = "/"
+			}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+			for key, values := range tt.header {
+				c.Request.Header[key] = values
+			}
+			c.Params = tt.params
+
+			got, err := tt.extractor(c)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != coursePhaseID {
+				t.Errorf("expected %v, got %v (err=%v)", coursePhaseID, got, err)
+			}
+		})
+	}
+}
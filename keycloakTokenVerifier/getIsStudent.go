@@ -1,59 +1,85 @@
 package keycloakTokenVerifier
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakCoreRequests"
-	log "github.com/sirupsen/logrus"
 )
 
-// Important: This requires a CoursePhaseID as a parameter.
-func isStudentOfCoursePhaseMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		coursePhaseID, err := uuid.Parse(c.Param("coursePhaseID"))
-		if err != nil {
-			log.Error("Error parsing coursePhaseID: ", err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			return
-		}
+// ErrNotStudent is returned by the core if the user is not a student of the course. Use it with errors.Is.
+var ErrNotStudent = keycloakCoreRequests.ErrNotStudent
 
-		if coursePhaseID == uuid.Nil {
-			log.Error("Invalid coursePhaseID")
-			_ = c.AbortWithError(http.StatusBadRequest, errors.New("coursePhaseID missing"))
-			return
-		}
+// Important: This requires a course phase ID, read by the configured CoursePhaseIDExtractor.
+func (v *Verifier) isStudentOfCoursePhase(c *gin.Context) {
+	coursePhaseID, err := v.coursePhaseID(c)
+	if err != nil {
+		v.abortWithCoursePhaseIDError(c, err)
+		return
+	}
+
+	tokenUser, ok := GetTokenUser(c)
+	if !ok {
+		v.logger.Error("Error getting token student")
+		abortWithProblem(c, http.StatusInternalServerError, ProblemInternal, ErrUserNotInContext.Error())
+		return
+	}
+
+	// request from the core (or the cache) if the user is a student of the course phase
+	student, err := v.getStudentOfCoursePhase(c.Request.Context(), c.GetHeader("Authorization"), coursePhaseID, tokenUser.ID)
+	if err != nil {
+		v.logger.Error("Error getting course roles:", err)
+		abortWithCoreError(c, err)
+		return
+	}
+
+	if !student.IsStudentOfCourse {
+		v.setLegacyContextKey(c, "isStudentOfCourse", false)
+		v.setLegacyContextKey(c, "isStudentOfCoursePhase", false)
+		return
+	}
 
-		// TODO: Wrap this around a caching component
-		// request from the core if the user is a student of the course phase
-		isStudentResponse, err := keycloakCoreRequests.SendIsStudentRequest(KeycloakTokenVerifierSingleton.CoreURL, c.GetHeader("Authorization"), coursePhaseID)
-		if err != nil {
-			if err.Error() == "not student of course" {
-				c.Set("isStudentOfCourse", false)
-				c.Set("isStudentOfCoursePhase", false)
-			} else {
-				log.Error("Error getting course roles:", err)
-				_ = c.AbortWithError(http.StatusInternalServerError, err)
-				return
-			}
-		} else {
-			// DEPRECATED: Keep this for backwards compatibility
-			c.Set("isStudentOfCourse", true)
-			c.Set("isStudentOfCoursePhase", isStudentResponse.IsStudentOfCoursePhase)
-			c.Set("courseParticipationID", isStudentResponse.CourseParticipationID)
-
-			tokenUser, ok := GetTokenUser(c)
-			if !ok {
-				log.Error("Error getting token student")
-				_ = c.AbortWithError(http.StatusInternalServerError, ErrUserNotInContext)
-				return
-			}
-			tokenUser.IsStudentOfCourse = true
-			tokenUser.IsStudentOfCoursePhase = isStudentResponse.IsStudentOfCoursePhase
-			tokenUser.CourseParticipationID = isStudentResponse.CourseParticipationID
-			SetTokenUser(c, tokenUser)
+	isStudentResponse := student.Participation
+
+	// DEPRECATED: Keep this for backwards compatibility, see LegacyContextKeys()
+	v.setLegacyContextKey(c, "isStudentOfCourse", true)
+	v.setLegacyContextKey(c, "isStudentOfCoursePhase", isStudentResponse.IsStudentOfCoursePhase)
+	v.setLegacyContextKey(c, "courseParticipationID", isStudentResponse.CourseParticipationID)
+
+	tokenUser.IsStudentOfCourse = true
+	tokenUser.IsStudentOfCoursePhase = isStudentResponse.IsStudentOfCoursePhase
+	tokenUser.CourseParticipationID = isStudentResponse.CourseParticipationID
+	SetTokenUser(c, tokenUser)
+}
+
+// getStudentOfCoursePhase requests from the core whether the user is a student of the course phase
+// and serves the result from the configured CoursePhaseCache if possible.
+// "Not student of course" is returned as an entry with IsStudentOfCourse = false and is cached as well.
+func (v *Verifier) getStudentOfCoursePhase(ctx context.Context, authHeader string, coursePhaseID uuid.UUID, subject string) (StudentCacheEntry, error) {
+	cache := v.getCoursePhaseCache()
+	key := CoursePhaseCacheKey{CoursePhaseID: coursePhaseID, Subject: subject}
+	if cache != nil {
+		if entry, ok := cache.GetStudent(key); ok {
+			return entry, nil
 		}
 	}
+
+	isStudentResponse, err := v.core.SendIsStudentRequest(ctx, authHeader, coursePhaseID)
+	var entry StudentCacheEntry
+	switch {
+	case errors.Is(err, ErrNotStudent):
+		entry = StudentCacheEntry{IsStudentOfCourse: false}
+	case err != nil:
+		return StudentCacheEntry{}, err
+	default:
+		entry = StudentCacheEntry{IsStudentOfCourse: true, Participation: isStudentResponse}
+	}
+
+	if cache != nil {
+		cache.SetStudent(key, entry)
+	}
+	return entry, nil
 }
@@ -1,61 +1,72 @@
 package keycloakTokenVerifier
 
 import (
-	"errors"
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakCoreRequests"
-	log "github.com/sirupsen/logrus"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
 )
 
-// Important: This requires a CoursePhaseID as a parameter.
-func getLecturerAndEditorRole() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		coursePhaseID, err := uuid.Parse(c.Param("coursePhaseID"))
-		if err != nil {
-			log.Error("Error parsing coursePhaseID:", err)
-			_ = c.AbortWithError(http.StatusBadRequest, err)
-			return
-		}
+// Important: This requires a course phase ID, read by the configured CoursePhaseIDExtractor.
+func (v *Verifier) getLecturerAndEditorRole(c *gin.Context) {
+	coursePhaseID, err := v.coursePhaseID(c)
+	if err != nil {
+		v.abortWithCoursePhaseIDError(c, err)
+		return
+	}
 
-		if coursePhaseID == uuid.Nil {
-			log.Error("Invalid coursePhaseID")
-			_ = c.AbortWithError(http.StatusBadRequest, errors.New("coursePhaseID missing"))
-			return
-		}
+	// get roles from the context
+	tokenUser, ok := GetTokenUser(c)
+	if !ok {
+		v.logger.Error("Error getting token student")
+		abortWithProblem(c, http.StatusInternalServerError, ProblemInternal, ErrUserNotInContext.Error())
+		return
+	}
+	userRoles := tokenUser.Roles
 
-		// TODO: Wrap this around a caching component
-		// retrieve the relevant roles from the core
-		tokenMapping, err := keycloakCoreRequests.SendCoursePhaseRoleMappingRequest(KeycloakTokenVerifierSingleton.CoreURL, c.GetHeader("Authorization"), coursePhaseID)
-		if err != nil {
-			log.Error("Error getting course roles:", err)
-			_ = c.AbortWithError(http.StatusInternalServerError, err)
-			return
-		}
+	// retrieve the relevant roles from the core (or the cache)
+	tokenMapping, err := v.getCoursePhaseRoleMapping(c.Request.Context(), c.GetHeader("Authorization"), coursePhaseID, tokenUser.ID)
+	if err != nil {
+		v.logger.Error("Error getting course roles:", err)
+		abortWithCoreError(c, err)
+		return
+	}
 
-		// get roles from the context
-		tokenUser, ok := GetTokenUser(c)
-		if !ok {
-			log.Error("Error getting token student")
-			_ = c.AbortWithError(http.StatusInternalServerError, ErrUserNotInContext)
-			return
-		}
-		userRoles := tokenUser.Roles
+	// filter out the roles relevant for the current course phase
+	isLecturer := userRoles[tokenMapping.CourseLecturerRole]
+	isEditor := userRoles[tokenMapping.CourseEditorRole]
 
-		// filter out the roles relevant for the current course phase
-		isLecturer := userRoles[tokenMapping.CourseLecturerRole]
-		isEditor := userRoles[tokenMapping.CourseEditorRole]
+	// DEPRECATED: Keep this for backwards compatibility, see LegacyContextKeys()
+	v.setLegacyContextKey(c, "isLecturer", isLecturer)
+	v.setLegacyContextKey(c, "isEditor", isEditor)
+	v.setLegacyContextKey(c, "customRolePrefix", tokenMapping.CustomRolePrefix)
 
-		// DEPRECATED: Keep this for backwards compatibility
-		c.Set("isLecturer", isLecturer)
-		c.Set("isEditor", isEditor)
-		c.Set("customRolePrefix", tokenMapping.CustomRolePrefix)
+	tokenUser.IsLecturer = isLecturer
+	tokenUser.IsEditor = isEditor
+	tokenUser.CustomRolePrefix = tokenMapping.CustomRolePrefix
+	SetTokenUser(c, tokenUser)
+}
+
+// getCoursePhaseRoleMapping requests the role mapping of the course phase from the core
+// and serves it from the configured CoursePhaseCache if possible.
+func (v *Verifier) getCoursePhaseRoleMapping(ctx context.Context, authHeader string, coursePhaseID uuid.UUID, subject string) (keycloakTokenVerifierDTO.GetCourseRoles, error) {
+	cache := v.getCoursePhaseCache()
+	key := CoursePhaseCacheKey{CoursePhaseID: coursePhaseID, Subject: subject}
+	if cache != nil {
+		if tokenMapping, ok := cache.GetRoleMapping(key); ok {
+			return tokenMapping, nil
+		}
+	}
+
+	tokenMapping, err := v.core.SendCoursePhaseRoleMappingRequest(ctx, authHeader, coursePhaseID)
+	if err != nil {
+		return keycloakTokenVerifierDTO.GetCourseRoles{}, err
+	}
 
-		tokenUser.IsLecturer = isLecturer
-		tokenUser.IsEditor = isEditor
-		tokenUser.CustomRolePrefix = tokenMapping.CustomRolePrefix
-		SetTokenUser(c, tokenUser)
+	if cache != nil {
+		cache.SetRoleMapping(key, tokenMapping)
 	}
+	return tokenMapping, nil
 }
@@ -0,0 +1,176 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+const (
+	introspectionContextKey       = "tokenIntrospection"
+	defaultIntrospectionCacheTTL  = 30 * time.Second
+	defaultIntrospectionCacheSize = 10000
+	// introspectionCacheSweepInterval is the number of cached results after which expired results are removed.
+	introspectionCacheSweepInterval = 256
+)
+
+// ErrIntrospectionNotConfigured is reported if UseIntrospection is used without WithIntrospection.
+var ErrIntrospectionNotConfigured = errors.New("token introspection not configured")
+
+// IntrospectionConfig configures the token introspection of UseIntrospection routes.
+type IntrospectionConfig struct {
+	// ClientID and ClientSecret authenticate the confidential client calling the introspection endpoint.
+	ClientID     string
+	ClientSecret string
+	// Endpoint is the introspection endpoint (default {KeycloakURL}/realms/{Realm}/protocol/openid-connect/token/introspect).
+	Endpoint string
+	// CacheTTL is how long the result for a token is reused (default 30s, at most until the token expires).
+	// Revocations take effect after at most CacheTTL.
+	CacheTTL time.Duration
+	// CacheSize limits the number of cached results (default 10000). If the cache is full, a random result is evicted.
+	CacheSize int
+}
+
+// UseIntrospection returns a handler that makes the middlewares of the following handlers check the token with
+// Keycloak's introspection endpoint in addition to the local signature check, e.g. for a grading or admin group:
+//
+//	admin := router.Group("/admin", UseIntrospection())
+//	admin.GET("/settings", AuthenticationMiddleware(PromptAdmin), handler)
+//
+// Revoked tokens and ended sessions are then rejected with 401 before they expire.
+// Opaque (non-JWT) tokens are only accepted on these routes, with the claims returned by the introspection.
+func UseIntrospection() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(introspectionContextKey, true)
+	}
+}
+
+func introspectionRequested(c *gin.Context) bool {
+	return c.GetBool(introspectionContextKey)
+}
+
+type introspectionResult struct {
+	active    bool
+	claims    map[string]interface{}
+	expiresAt time.Time
+}
+
+// introspector calls the introspection endpoint and caches the results by the hash of the token.
+type introspector struct {
+	config     IntrospectionConfig
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]introspectionResult
+	// inserts counts the cached results since the last sweep.
+	inserts int
+}
+
+func newIntrospector(k *KeycloakTokenVerifier) *introspector {
+	if k.introspection == nil {
+		return nil
+	}
+	config := *k.introspection
+	if config.Endpoint == "" {
+		config.Endpoint = k.KeycloakURL.JoinPath("realms", k.Realm, "protocol/openid-connect/token/introspect").String()
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaultIntrospectionCacheTTL
+	}
+	if config.CacheSize <= 0 {
+		config.CacheSize = defaultIntrospectionCacheSize
+	}
+	return &introspector{
+		config:     config,
+		httpClient: k.keycloakHTTPClient(),
+		now:        time.Now,
+		cache:      make(map[[sha256.Size]byte]introspectionResult),
+	}
+}
+
+// introspect returns whether the token is active and its claims, from the cache if possible.
+func (i *introspector) introspect(ctx context.Context, token string) (bool, map[string]interface{}, error) {
+	key := sha256.Sum256([]byte(token))
+	now := i.now()
+
+	i.mu.Lock()
+	cached, ok := i.cache[key]
+	i.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.active, cached.claims, nil
+	}
+
+	active, claims, err := i.requestIntrospection(ctx, token)
+	if err != nil {
+		return false, nil, err
+	}
+
+	result := introspectionResult{active: active, claims: claims, expiresAt: now.Add(i.config.CacheTTL)}
+	if exp, ok := claims["exp"].(float64); ok && time.Unix(int64(exp), 0).Before(result.expiresAt) {
+		result.expiresAt = time.Unix(int64(exp), 0)
+	}
+
+	i.store(key, result, now)
+	return active, claims, nil
+}
+
+// store caches the result. Expired results are only removed every introspectionCacheSweepInterval inserts,
+// so that a flood of unknown tokens does not scan the cache on every request.
+func (i *introspector) store(key [sha256.Size]byte, result introspectionResult, now time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.inserts++
+	if i.inserts >= introspectionCacheSweepInterval {
+		i.inserts = 0
+		for cachedKey, cachedResult := range i.cache {
+			if !now.Before(cachedResult.expiresAt) {
+				delete(i.cache, cachedKey)
+			}
+		}
+	}
+	if _, exists := i.cache[key]; !exists && len(i.cache) >= i.config.CacheSize {
+		for cachedKey := range i.cache {
+			delete(i.cache, cachedKey)
+			break
+		}
+	}
+	i.cache[key] = result
+}
+
+func (i *introspector) requestIntrospection(ctx context.Context, token string) (bool, map[string]interface{}, error) {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.config.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(i.config.ClientID), url.QueryEscape(i.config.ClientSecret))
+
+	resp, err := i.httpClient.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, nil, utils.NewUpstreamError(resp)
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return false, nil, err
+	}
+	active, _ := claims["active"].(bool)
+	return active, claims, nil
+}
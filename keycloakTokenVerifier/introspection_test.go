@@ -0,0 +1,176 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+func newIntrospectionRouter(t *testing.T, kc *keycloakTesting.Server, opts ...Option) *gin.Engine {
+	t.Helper()
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid", opts...)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	handler := func(c *gin.Context) {
+		tokenUser, _ := GetTokenUser(c)
+		c.String(http.StatusOK, tokenUser.Email)
+	}
+	router := gin.New()
+	router.GET("/local", v.AuthenticationMiddleware(PromptAdmin), handler)
+	admin := router.Group("/admin", UseIntrospection())
+	admin.GET("/settings", v.AuthenticationMiddleware(PromptAdmin), handler)
+	return router
+}
+
+func serve(router *gin.Engine, path, authHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", authHeader)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestVerifier_Introspection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	kc.AddServiceAccount("module", "secret")
+	router := newIntrospectionRouter(t, kc, WithIntrospection(IntrospectionConfig{ClientID: "module", ClientSecret: "secret"}))
+
+	revoked := kc.Token().Roles(PromptAdmin).MustSign()
+	kc.Revoke(revoked)
+	opaque := kc.Token().Email("admin@tum.de").Roles(PromptAdmin).Opaque()
+
+	tests := []struct {
+		name       string
+		path       string
+		authHeader string
+		wantStatus int
+	}{
+		{"active token", "/admin/settings", kc.Token().Roles(PromptAdmin).BearerHeader(), http.StatusOK},
+		{"revoked token", "/admin/settings", "Bearer " + revoked, http.StatusUnauthorized},
+		{"revoked token without introspection", "/local", "Bearer " + revoked, http.StatusOK},
+		{"opaque token", "/admin/settings", "Bearer " + opaque, http.StatusOK},
+		{"opaque token without introspection", "/local", "Bearer " + opaque, http.StatusUnauthorized},
+		{"unknown opaque token", "/admin/settings", "Bearer unknown", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rec := serve(router, tt.path, tt.authHeader)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d; want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	if rec := serve(router, "/admin/settings", "Bearer "+opaque); rec.Body.String() != "admin@tum.de" {
+		t.Errorf("email of opaque token = %q; want the introspected claim", rec.Body.String())
+	}
+}
+
+func TestVerifier_Introspection_Cache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	kc.AddServiceAccount("module", "secret")
+	router := newIntrospectionRouter(t, kc, WithIntrospection(IntrospectionConfig{ClientID: "module", ClientSecret: "secret", CacheTTL: time.Hour}))
+
+	token := kc.Token().Roles(PromptAdmin).MustSign()
+	for range 3 {
+		if rec := serve(router, "/admin/settings", "Bearer "+token); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d; want %d", rec.Code, http.StatusOK)
+		}
+	}
+	if count := kc.IntrospectionCount(); count != 1 {
+		t.Errorf("introspections = %d; want 1", count)
+	}
+}
+
+func TestVerifier_Introspection_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	kc.AddServiceAccount("module", "secret")
+	token := kc.Token().Roles(PromptAdmin).BearerHeader()
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		wantStatus int
+		wantCode   string
+	}{
+		{"not configured", nil, http.StatusInternalServerError, ProblemInternal},
+		{"invalid client", []Option{WithIntrospection(IntrospectionConfig{ClientID: "module", ClientSecret: "wrong"})}, http.StatusServiceUnavailable, ProblemIntrospectionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(newIntrospectionRouter(t, kc, tt.opts...), "/admin/settings", token)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d; want %d", rec.Code, tt.wantStatus)
+			}
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if problem.Code != tt.wantCode {
+				t.Errorf("code = %q; want %q", problem.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestIntrospector_CacheSize(t *testing.T) {
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	kc.AddServiceAccount("module", "secret")
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid",
+		WithIntrospection(IntrospectionConfig{ClientID: "module", ClientSecret: "secret", CacheSize: 2}))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+
+	for range 5 {
+		if _, _, err := v.introspector.introspect(context.Background(), kc.Token().Opaque()); err != nil {
+			t.Fatalf("introspect() error = %v", err)
+		}
+	}
+	if size := len(v.introspector.cache); size != 2 {
+		t.Errorf("cache size = %d; want 2", size)
+	}
+}
+
+func TestIntrospector_HTTPClient(t *testing.T) {
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	config := IntrospectionConfig{ClientID: "module", ClientSecret: "secret"}
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid", WithIntrospection(config))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+	if v.introspector.httpClient == utils.HTTPClient() {
+		t.Errorf("introspection uses the shared SDK client and its circuit breakers")
+	}
+
+	custom := &http.Client{}
+	v, err = NewVerifier(kc.URL(), kc.Realm, "http://core.invalid", WithIntrospection(config), WithHTTPClient(custom))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+	if v.introspector.httpClient != custom {
+		t.Errorf("introspection does not use the client of WithHTTPClient")
+	}
+}
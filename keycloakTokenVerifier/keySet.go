@@ -0,0 +1,202 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+// minUnknownKeyIDRefreshInterval limits the JWKS refreshes caused by tokens with an unknown key ID,
+// so that forged tokens cannot flood Keycloak.
+const minUnknownKeyIDRefreshInterval = 10 * time.Second
+
+// ErrUnknownKeyID is returned for tokens signed with a key that is not in the JWKS of the realm, even after a refresh.
+var ErrUnknownKeyID = errors.New("token signed with unknown key ID")
+
+var supportedSigningAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.EdDSA,
+}
+
+// keySet is the JWKS of the realm, an oidc.KeySet that refreshes the keys in the background and
+// on unknown key IDs and counts the key IDs of the verified tokens.
+type keySet struct {
+	url        string
+	httpClient *http.Client
+	metrics    VerifierMetrics
+	now        func() time.Time
+
+	// refreshMu serializes the refreshes.
+	refreshMu sync.Mutex
+
+	mu                 sync.RWMutex
+	keys               []jose.JSONWebKey
+	refreshedAt        time.Time
+	lastRefreshErr     error
+	lastRefreshTry     time.Time
+	lastUnknownRefresh time.Time
+	keyIDsSeen         map[string]uint64
+	unknownKeyIDCount  uint64
+}
+
+func newKeySet(url string, httpClient *http.Client, metrics VerifierMetrics) *keySet {
+	return &keySet{
+		url:        url,
+		httpClient: httpClient,
+		metrics:    metrics,
+		now:        time.Now,
+		keyIDsSeen: make(map[string]uint64),
+	}
+}
+
+// VerifySignature implements oidc.KeySet.
+func (k *keySet) VerifySignature(ctx context.Context, jwt string) ([]byte, error) {
+	jws, err := jose.ParseSigned(jwt, supportedSigningAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt: %w", err)
+	}
+	keyID := jws.Signatures[0].Header.KeyID
+
+	keys := k.keysFor(keyID)
+	if len(keys) == 0 {
+		k.refreshForUnknownKeyID(ctx)
+		keys = k.keysFor(keyID)
+	}
+	if len(keys) == 0 {
+		k.mu.Lock()
+		k.unknownKeyIDCount++
+		k.mu.Unlock()
+		if k.metrics.OnUnknownKeyID != nil {
+			k.metrics.OnUnknownKeyID(keyID)
+		}
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, keyID)
+	}
+
+	for _, key := range keys {
+		if payload, err := jws.Verify(key); err == nil {
+			k.mu.Lock()
+			k.keyIDsSeen[key.KeyID]++
+			k.mu.Unlock()
+			if k.metrics.OnKeySeen != nil {
+				k.metrics.OnKeySeen(key.KeyID)
+			}
+			return payload, nil
+		}
+	}
+	return nil, errors.New("failed to verify signature")
+}
+
+// keysFor returns the key with keyID, or all keys if the token has no key ID.
+func (k *keySet) keysFor(keyID string) []jose.JSONWebKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if keyID == "" {
+		return k.keys
+	}
+	for _, key := range k.keys {
+		if key.KeyID == keyID {
+			return []jose.JSONWebKey{key}
+		}
+	}
+	return nil
+}
+
+// refreshForUnknownKeyID refreshes the keys, e.g. after Keycloak rotated them,
+// unless an unknown key ID caused a refresh less than minUnknownKeyIDRefreshInterval ago.
+func (k *keySet) refreshForUnknownKeyID(ctx context.Context) {
+	k.mu.Lock()
+	now := k.now()
+	recent := !k.lastUnknownRefresh.IsZero() && now.Sub(k.lastUnknownRefresh) < minUnknownKeyIDRefreshInterval
+	if !recent {
+		k.lastUnknownRefresh = now
+	}
+	k.mu.Unlock()
+	if !recent {
+		_ = k.refresh(ctx)
+	}
+}
+
+// refresh replaces the keys with the current JWKS of the realm. On error the previous keys are kept.
+func (k *keySet) refresh(ctx context.Context) error {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	keys, err := k.fetch(ctx)
+
+	k.mu.Lock()
+	k.lastRefreshTry = k.now()
+	k.lastRefreshErr = err
+	if err == nil {
+		k.keys = keys
+		k.refreshedAt = k.lastRefreshTry
+	}
+	k.mu.Unlock()
+
+	if k.metrics.OnKeyRefresh != nil {
+		k.metrics.OnKeyRefresh(keyIDs(keys), err)
+	}
+	return err
+}
+
+func (k *keySet) fetch(ctx context.Context) ([]jose.JSONWebKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch keys: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, utils.NewUpstreamError(resp)
+	}
+
+	var jwks jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("failed to decode keys: %w", err)
+	}
+	keys := make([]jose.JSONWebKey, 0, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if key.Use == "" || key.Use == "sig" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// status fills the key fields of the status.
+func (k *keySet) status(s *VerifierStatus) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	s.KeyIDs = keyIDs(k.keys)
+	s.KeysRefreshedAt = k.refreshedAt
+	if k.lastRefreshErr != nil {
+		s.LastKeyRefreshError = k.lastRefreshErr.Error()
+	}
+	s.KeyIDsSeen = make(map[string]uint64, len(k.keyIDsSeen))
+	for keyID, count := range k.keyIDsSeen {
+		s.KeyIDsSeen[keyID] = count
+	}
+	s.UnknownKeyIDFailures = k.unknownKeyIDCount
+}
+
+func keyIDs(keys []jose.JSONWebKey) []string {
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key.KeyID)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,111 @@
+package keycloakCoreRequests_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/coretest"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakCoreRequests"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	"github.com/ls1intum/prompt-sdk/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T) (*coretest.Server, *keycloakCoreRequests.Client) {
+	t.Helper()
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+	core.SubjectFromAuthHeader = func(authHeader string) (string, bool) { return "user", true }
+	coreURL, err := url.Parse(core.URL())
+	require.NoError(t, err)
+	return core, keycloakCoreRequests.NewClient(*coreURL, nil, nil)
+}
+
+func TestSendIsStudentRequest_NotStudent(t *testing.T) {
+	core, client := newTestClient(t)
+	coursePhaseID := uuid.New()
+	core.AddStudent(coursePhaseID, "other", keycloakTokenVerifierDTO.GetCoursePhaseParticipation{IsStudentOfCoursePhase: true})
+
+	_, err := client.SendIsStudentRequest(context.Background(), "Bearer token", coursePhaseID)
+	require.ErrorIs(t, err, keycloakCoreRequests.ErrNotStudent)
+
+	var upstreamErr *utils.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusUnauthorized, upstreamErr.StatusCode)
+}
+
+func TestSendCoursePhaseRoleMappingRequest_NonOK(t *testing.T) {
+	core, client := newTestClient(t)
+	coursePhaseID := uuid.New()
+	core.Fail("/api/auth/course_phase/"+coursePhaseID.String()+"/roles", http.StatusForbidden)
+
+	_, err := client.SendCoursePhaseRoleMappingRequest(context.Background(), "Bearer token", coursePhaseID)
+	var upstreamErr *utils.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusForbidden, upstreamErr.StatusCode)
+	assert.Equal(t, http.MethodGet, upstreamErr.Method)
+	assert.Contains(t, upstreamErr.URL, coursePhaseID.String())
+	assert.Contains(t, upstreamErr.Body, "configured failure")
+	assert.False(t, upstreamErr.Retryable)
+	assert.NotErrorIs(t, err, keycloakCoreRequests.ErrNotStudent)
+}
+
+func TestClient_AuthProvider(t *testing.T) {
+	core, client := newTestClient(t)
+	core.SubjectFromAuthHeader = func(authHeader string) (string, bool) {
+		return "service", authHeader == "Bearer service"
+	}
+	client.AuthProvider = utils.StaticAuthHeader("Bearer service")
+	coursePhaseID := uuid.New()
+	core.AddStudent(coursePhaseID, "service", keycloakTokenVerifierDTO.GetCoursePhaseParticipation{IsStudentOfCoursePhase: true})
+
+	participation, err := client.SendIsStudentRequest(context.Background(), "", coursePhaseID)
+	require.NoError(t, err)
+	assert.True(t, participation.IsStudentOfCoursePhase)
+}
+
+func TestSendCoursePhasesAccessRequest_ReprobesBatchEndpoint(t *testing.T) {
+	core, client := newTestClient(t)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	client.SetNow(func() time.Time { return now })
+	coursePhaseID := uuid.New()
+	core.SetRoleMapping(coursePhaseID, keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios25-Lecturer"})
+	core.SetBatchSupported(false)
+
+	for range 2 {
+		_, err := client.SendCoursePhasesAccessRequest(context.Background(), "Bearer token", []uuid.UUID{coursePhaseID})
+		require.ErrorIs(t, err, keycloakCoreRequests.ErrBatchNotSupported)
+	}
+	assert.Equal(t, 1, core.RequestCount("/api/auth/course_phases/access"), "the missing batch endpoint is remembered")
+
+	core.SetBatchSupported(true)
+	now = now.Add(10 * time.Minute)
+	access, err := client.SendCoursePhasesAccessRequest(context.Background(), "Bearer token", []uuid.UUID{coursePhaseID})
+	require.NoError(t, err)
+	assert.Equal(t, "ios25-Lecturer", access[coursePhaseID].Roles.CourseLecturerRole)
+}
+
+func TestPackageDefaults_Concurrent(t *testing.T) {
+	coreURL, err := url.Parse("http://core.invalid")
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			keycloakCoreRequests.SetHTTPClient(nil)
+			keycloakCoreRequests.SetLogger(nil)
+		}
+	}()
+	for range 100 {
+		client := keycloakCoreRequests.NewClient(*coreURL, nil, nil)
+		require.NotNil(t, client.HTTPClient)
+		require.NotNil(t, client.Logger)
+	}
+	<-done
+}
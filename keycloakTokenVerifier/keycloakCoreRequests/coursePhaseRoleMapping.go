@@ -1,6 +1,7 @@
 package keycloakCoreRequests
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/url"
@@ -8,30 +9,41 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
-	log "github.com/sirupsen/logrus"
+	"github.com/ls1intum/prompt-sdk/utils"
 )
 
+// SendCoursePhaseRoleMappingRequest requests the role mapping of the course phase from the core.
+// A non-200 response is returned as *utils.UpstreamError.
 func SendCoursePhaseRoleMappingRequest(coreURL url.URL, authHeader string, coursePhaseID uuid.UUID) (keycloakTokenVerifierDTO.GetCourseRoles, error) {
+	return SendCoursePhaseRoleMappingRequestCtx(context.Background(), coreURL, authHeader, coursePhaseID)
+}
+
+// SendCoursePhaseRoleMappingRequestCtx is SendCoursePhaseRoleMappingRequest bound to ctx, e.g. the context of the incoming request.
+func SendCoursePhaseRoleMappingRequestCtx(ctx context.Context, coreURL url.URL, authHeader string, coursePhaseID uuid.UUID) (keycloakTokenVerifierDTO.GetCourseRoles, error) {
+	return NewClient(coreURL, nil, nil).SendCoursePhaseRoleMappingRequest(ctx, authHeader, coursePhaseID)
+}
+
+func (c *Client) SendCoursePhaseRoleMappingRequest(ctx context.Context, authHeader string, coursePhaseID uuid.UUID) (keycloakTokenVerifierDTO.GetCourseRoles, error) {
 	path := path.Join("/api/auth/course_phase", coursePhaseID.String(), "roles")
 
-	resp, err := sendRequest(coreURL, "GET", path, authHeader, nil)
+	resp, err := c.sendRequest(ctx, "GET", path, authHeader, nil)
 	if err != nil {
 		return keycloakTokenVerifierDTO.GetCourseRoles{}, err
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			log.Error("failed to close response body:", closeErr)
+			c.Logger.Error("failed to close response body:", closeErr)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Error("Received non-OK response:", resp.Status)
-		return keycloakTokenVerifierDTO.GetCourseRoles{}, nil
+		c.Logger.Error("Received non-OK response:", resp.Status)
+		return keycloakTokenVerifierDTO.GetCourseRoles{}, utils.NewUpstreamError(resp)
 	}
 
 	var authResponse keycloakTokenVerifierDTO.GetCourseRoles
 	if err = json.NewDecoder(resp.Body).Decode(&authResponse); err != nil {
-		log.Error("Error decoding response body:", err)
+		c.Logger.Error("Error decoding response body:", err)
 		return keycloakTokenVerifierDTO.GetCourseRoles{}, err
 	}
 
@@ -0,0 +1,65 @@
+package keycloakCoreRequests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+// batchUnsupportedRetryInterval is how long the batch endpoint is not requested after the core responded that it has none,
+// so that a single 404 of a proxy during a deployment does not disable batching until a restart.
+const batchUnsupportedRetryInterval = 10 * time.Minute
+
+// ErrBatchNotSupported is returned by SendCoursePhasesAccessRequest if the core has no batch endpoint.
+var ErrBatchNotSupported = errors.New("core does not support batch course phase access requests")
+
+// SendCoursePhasesAccessRequest requests the role mappings and the student status of the user for several
+// course phases at once. Course phases unknown to the core are missing from the result.
+// If the core has no batch endpoint, ErrBatchNotSupported is returned; the client remembers this for
+// 10 minutes and returns the error without a request in the meantime.
+func (c *Client) SendCoursePhasesAccessRequest(ctx context.Context, authHeader string, coursePhaseIDs []uuid.UUID) (map[uuid.UUID]keycloakTokenVerifierDTO.GetCoursePhaseAccess, error) {
+	if c.currentTime().UnixNano() < c.batchUnsupportedUntil.Load() {
+		return nil, ErrBatchNotSupported
+	}
+
+	body, err := json.Marshal(keycloakTokenVerifierDTO.GetCoursePhasesAccessRequest{CoursePhaseIDs: coursePhaseIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.sendRequest(ctx, "POST", "/api/auth/course_phases/access", authHeader, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.Logger.Error("failed to close response body:", closeErr)
+		}
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		c.Logger.Info("Core does not support batch course phase access requests, falling back to single requests")
+		c.batchUnsupportedUntil.Store(c.currentTime().Add(batchUnsupportedRetryInterval).UnixNano())
+		return nil, ErrBatchNotSupported
+	default:
+		c.Logger.Error("Received non-OK response:", resp.Status)
+		return nil, utils.NewUpstreamError(resp)
+	}
+
+	var accessResponse map[uuid.UUID]keycloakTokenVerifierDTO.GetCoursePhaseAccess
+	if err = json.NewDecoder(resp.Body).Decode(&accessResponse); err != nil {
+		c.Logger.Error("Error decoding response body:", err)
+		return nil, err
+	}
+
+	return accessResponse, nil
+}
@@ -0,0 +1,8 @@
+package keycloakCoreRequests
+
+import "time"
+
+// SetNow replaces the clock of the client in tests.
+func (c *Client) SetNow(now func() time.Time) {
+	c.now = now
+}
@@ -1,43 +1,60 @@
 package keycloakCoreRequests
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"path"
 
 	"github.com/google/uuid"
 	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
-	log "github.com/sirupsen/logrus"
+	"github.com/ls1intum/prompt-sdk/utils"
 )
 
+// ErrNotStudent is returned by SendIsStudentRequest if the user is not a student of the course.
+// The error also wraps the *utils.UpstreamError of the response.
+var ErrNotStudent = errors.New("not student of course")
+
+// SendIsStudentRequest requests from the core whether the user is a student of the course phase.
+// A non-200 response is returned as *utils.UpstreamError, a 401 additionally as ErrNotStudent.
 func SendIsStudentRequest(coreURL url.URL, authHeader string, coursePhaseID uuid.UUID) (keycloakTokenVerifierDTO.GetCoursePhaseParticipation, error) {
+	return SendIsStudentRequestCtx(context.Background(), coreURL, authHeader, coursePhaseID)
+}
+
+// SendIsStudentRequestCtx is SendIsStudentRequest bound to ctx, e.g. the context of the incoming request.
+func SendIsStudentRequestCtx(ctx context.Context, coreURL url.URL, authHeader string, coursePhaseID uuid.UUID) (keycloakTokenVerifierDTO.GetCoursePhaseParticipation, error) {
+	return NewClient(coreURL, nil, nil).SendIsStudentRequest(ctx, authHeader, coursePhaseID)
+}
+
+func (c *Client) SendIsStudentRequest(ctx context.Context, authHeader string, coursePhaseID uuid.UUID) (keycloakTokenVerifierDTO.GetCoursePhaseParticipation, error) {
 	path := path.Join("/api/auth/course_phase", coursePhaseID.String(), "is_student")
 
-	resp, err := sendRequest(coreURL, "GET", path, authHeader, nil)
+	resp, err := c.sendRequest(ctx, "GET", path, authHeader, nil)
 	if err != nil {
 		return keycloakTokenVerifierDTO.GetCoursePhaseParticipation{}, err
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			log.Error("failed to close response body:", closeErr)
+			c.Logger.Error("failed to close response body:", closeErr)
 		}
 	}()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		log.Info("Not student of course")
-		return keycloakTokenVerifierDTO.GetCoursePhaseParticipation{IsStudentOfCoursePhase: false}, errors.New("not student of course")
+		c.Logger.Info("Not student of course")
+		return keycloakTokenVerifierDTO.GetCoursePhaseParticipation{IsStudentOfCoursePhase: false}, fmt.Errorf("%w: %w", ErrNotStudent, utils.NewUpstreamError(resp))
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.Error("Received non-OK response:", resp.Status)
-		return keycloakTokenVerifierDTO.GetCoursePhaseParticipation{}, nil
+		c.Logger.Error("Received non-OK response:", resp.Status)
+		return keycloakTokenVerifierDTO.GetCoursePhaseParticipation{}, utils.NewUpstreamError(resp)
 	}
 
 	var isStudentResponse keycloakTokenVerifierDTO.GetCoursePhaseParticipation
 	if err = json.NewDecoder(resp.Body).Decode(&isStudentResponse); err != nil {
-		log.Error("Error decoding response body:", err)
+		c.Logger.Error("Error decoding response body:", err)
 		return keycloakTokenVerifierDTO.GetCoursePhaseParticipation{}, err
 	}
 
@@ -1,32 +1,118 @@
 package keycloakCoreRequests
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/url"
+	"sync/atomic"
 	"time"
 
+	"github.com/ls1intum/prompt-sdk/utils"
 	log "github.com/sirupsen/logrus"
 )
 
-var client = &http.Client{Timeout: 10 * time.Second}
+// The package defaults of NewClient. They may be set at any time, clients created before keep their values.
+var (
+	// client is nil by default, which uses the shared SDK client of utils.HTTPClient.
+	client       atomic.Pointer[http.Client]
+	logger       atomic.Pointer[log.FieldLogger]
+	authProvider atomic.Pointer[utils.AuthProvider]
+)
+
+// SetHTTPClient sets the HTTP client of the clients created by NewClient.
+// Passing nil restores the shared SDK client.
+func SetHTTPClient(httpClient *http.Client) {
+	client.Store(httpClient)
+}
+
+// SetLogger sets the logger of the clients created by NewClient. Passing nil restores the standard logger.
+func SetLogger(l log.FieldLogger) {
+	if l == nil {
+		logger.Store(nil)
+		return
+	}
+	logger.Store(&l)
+}
+
+// SetAuthProvider sets the AuthProvider of the clients created by NewClient, e.g. a
+// utils.ClientCredentialsTokenSource, used for requests passed an empty authHeader.
+func SetAuthProvider(auth utils.AuthProvider) {
+	if auth == nil {
+		authProvider.Store(nil)
+		return
+	}
+	authProvider.Store(&auth)
+}
+
+// Client sends the authentication related requests to one Prompt Core instance.
+type Client struct {
+	CoreURL    url.URL
+	HTTPClient *http.Client
+	Logger     log.FieldLogger
+	// AuthProvider supplies the Authorization header of requests passed an empty authHeader,
+	// e.g. a utils.ClientCredentialsTokenSource for requests from background jobs.
+	AuthProvider utils.AuthProvider
+
+	// batchUnsupportedUntil is the time (Unix nanoseconds) until which the batch endpoint is not requested again
+	// after the core responded that it has none.
+	batchUnsupportedUntil atomic.Int64
+	// now is time.Now if nil.
+	now func() time.Time
+}
+
+func (c *Client) currentTime() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
 
-func sendRequest(coreURL url.URL, method, subPath, authHeader string, body io.Reader) (*http.Response, error) {
-	requestURL := coreURL.JoinPath(subPath)
-	req, err := http.NewRequest(method, requestURL.String(), body)
+// NewClient creates a Client for the given core. A nil httpClient or logger falls back to the package defaults.
+func NewClient(coreURL url.URL, httpClient *http.Client, l log.FieldLogger) *Client {
+	if httpClient == nil {
+		httpClient = client.Load()
+	}
+	if httpClient == nil {
+		httpClient = utils.HTTPClient()
+	}
+	if l == nil {
+		if defaultLogger := logger.Load(); defaultLogger != nil {
+			l = *defaultLogger
+		} else {
+			l = log.StandardLogger()
+		}
+	}
+	var auth utils.AuthProvider
+	if defaultAuth := authProvider.Load(); defaultAuth != nil {
+		auth = *defaultAuth
+	}
+	return &Client{CoreURL: coreURL, HTTPClient: httpClient, Logger: l, AuthProvider: auth}
+}
+
+func (c *Client) sendRequest(ctx context.Context, method, subPath, authHeader string, body io.Reader) (*http.Response, error) {
+	requestURL := c.CoreURL.JoinPath(subPath)
+	req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), body)
 	if err != nil {
-		log.Error("Error creating request:", err)
+		c.Logger.Error("Error creating request:", err)
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if authHeader == "" && c.AuthProvider != nil {
+		authHeader, err = c.AuthProvider.AuthHeader(ctx)
+		if err != nil {
+			c.Logger.Error("Error getting auth header:", err)
+			return nil, err
+		}
+	}
 	if authHeader != "" {
 		req.Header.Set("Authorization", authHeader)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		log.Error("Error sending request:", err)
+		c.Logger.Error("Error sending request:", err)
 		return nil, err
 	}
 
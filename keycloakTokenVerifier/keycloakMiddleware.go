@@ -4,103 +4,202 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
-// Validates the token and extracts the claims from the token.
-func KeycloakMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		tokenString, err := extractBearerToken(c)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			return
-		}
+// KeycloakMiddleware validates the token and extracts the claims from the token.
+func (v *Verifier) KeycloakMiddleware() gin.HandlerFunc {
+	return v.keycloakMiddleware
+}
 
-		ctx := c.Request.Context()
-		idToken, err := verifier.Verify(ctx, tokenString)
-		if err != nil {
-			log.Error("Failed to validate token: ", err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			return
-		}
+func (v *Verifier) keycloakMiddleware(c *gin.Context) {
+	token, ok := v.verifyBearerToken(c)
+	if !ok {
+		return
+	}
+	claims := token.claims
 
-		claims, err := extractClaims(idToken)
-		if err != nil {
-			log.Error("Failed to parse claims: ", err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			return
-		}
+	var claimErr *TokenClaimError
+	if errors.As(checkAuthorizedParties(claims, v.allowedAuthorizedParties(c)), &claimErr) {
+		v.abortWithClaimError(c, claimErr)
+		return
+	}
 
-		if !checkAuthorizedParty(claims, KeycloakTokenVerifierSingleton.expectedAuthorizedParty) {
-			log.Error("Token authorized party mismatch")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token authorized party mismatch"})
-			return
-		}
+	// extract user Id
+	userID := token.subject
+	if userID == "" {
+		v.logger.Error("Failed to extract user ID (sub) from token claims")
+		abortUnauthenticated(c, ProblemInvalidToken, "Invalid user ID")
+		return
+	}
 
-		// extract user Id
-		userID, ok := claims["sub"].(string)
-		if !ok {
-			log.Error("Failed to extract user ID (sub) from token claims")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
-			return
-		}
+	userEmail, ok := claims["email"].(string)
+	if !ok {
+		v.logger.Error("Failed to extract user ID (sub) from token claims")
+	}
 
-		userEmail, ok := claims["email"].(string)
-		if !ok {
-			log.Error("Failed to extract user ID (sub) from token claims")
-		}
+	matriculationNumber, ok := claims["matriculation_number"].(string)
+	if !ok {
+		v.logger.Error("Failed to extract user matriculation number (sub) from token claims")
+	}
 
-		matriculationNumber, ok := claims["matriculation_number"].(string)
-		if !ok {
-			log.Error("Failed to extract user matriculation number (sub) from token claims")
-		}
+	universityLogin, ok := claims["university_login"].(string)
+	if !ok {
+		v.logger.Error("Failed to extract user university login (sub) from token claims")
+	}
 
-		universityLogin, ok := claims["university_login"].(string)
-		if !ok {
-			log.Error("Failed to extract user university login (sub) from token claims")
-		}
+	firstName, ok := claims["given_name"].(string)
+	if !ok {
+		v.logger.Error("Failed to extract user given name (sub) from token claims")
+	}
 
-		firstName, ok := claims["given_name"].(string)
-		if !ok {
-			log.Error("Failed to extract user given name (sub) from token claims")
-		}
+	lastName, ok := claims["family_name"].(string)
+	if !ok {
+		v.logger.Error("Failed to extract user family name (sub) from token claims")
+	}
 
-		lastName, ok := claims["family_name"].(string)
-		if !ok {
-			log.Error("Failed to extract user family name (sub) from token claims")
-		}
+	// Retrieve all user's roles from the configured role sources, by default the client roles of prompt-server (clientID)
+	userRoles, roleOrigins, err := v.checkKeycloakRoles(claims)
+	if err != nil {
+		abortUnauthenticated(c, ProblemInvalidToken, "could not authenticate user")
+		return
+	}
 
-		// Retrieve all user's roles from the token (if any) for the audience prompt-server (clientID)
-		userRoles, err := checkKeycloakRoles(claims)
+	// DEPRECATED: Leave this for backward compatibility, see LegacyContextKeys()
+	// Store the extracted roles in the context
+	v.setLegacyContextKey(c, "userRoles", userRoles)
+	v.setLegacyContextKey(c, "userID", userID)
+	v.setLegacyContextKey(c, "userEmail", userEmail)
+	v.setLegacyContextKey(c, "matriculationNumber", matriculationNumber)
+	v.setLegacyContextKey(c, "universityLogin", universityLogin)
+	v.setLegacyContextKey(c, "firstName", firstName)
+	v.setLegacyContextKey(c, "lastName", lastName)
+
+	sessionID, _ := claims["sid"].(string)
+	preferredUsername, _ := claims["preferred_username"].(string)
+
+	SetTokenUser(c, TokenUser{
+		Roles:               userRoles,
+		RoleOrigins:         roleOrigins,
+		ID:                  userID,
+		Email:               userEmail,
+		MatriculationNumber: matriculationNumber,
+		UniversityLogin:     universityLogin,
+		FirstName:           firstName,
+		LastName:            lastName,
+		ExpiresAt:           token.expiresAt,
+		IssuedAt:            token.issuedAt,
+		SessionID:           sessionID,
+		PreferredUsername:   preferredUsername,
+		RealmRoles:          extractRealmRoles(claims),
+		claims:              claims,
+	})
+}
+
+// verifiedToken is a token accepted by verifyBearerToken.
+type verifiedToken struct {
+	subject   string
+	expiresAt time.Time
+	issuedAt  time.Time
+	claims    map[string]interface{}
+}
+
+// verifyBearerToken verifies the bearer token of the request and returns its claims.
+// On UseIntrospection routes the token must also be active according to the introspection endpoint.
+// The aud claim must contain one of the allowed audiences of the route, if any.
+// If the token is missing or invalid, the request is aborted with 401.
+func (v *Verifier) verifyBearerToken(c *gin.Context) (token verifiedToken, ok bool) {
+	tokenString, err := extractBearerToken(c)
+	if err != nil {
+		abortUnauthenticated(c, ProblemUnauthenticated, err.Error())
+		return verifiedToken{}, false
+	}
+
+	introspect := introspectionRequested(c)
+	if introspect && v.introspector == nil {
+		v.logger.Error(ErrIntrospectionNotConfigured)
+		abortWithProblem(c, http.StatusInternalServerError, ProblemInternal, ErrIntrospectionNotConfigured.Error())
+		return verifiedToken{}, false
+	}
+
+	idToken, err := v.provider.verify(c.Request.Context(), tokenString)
+	switch {
+	case errors.Is(err, ErrVerifierNotReady):
+		v.logger.Error("Failed to validate token: ", err)
+		abortWithProblem(c, http.StatusServiceUnavailable, ProblemVerifierUnavailable, err.Error())
+		return verifiedToken{}, false
+	case err != nil && introspect && !isJWT(tokenString):
+		// opaque tokens can only be checked by Keycloak
+		if token, ok = v.introspectToken(c, tokenString); !ok {
+			return verifiedToken{}, false
+		}
+	case err != nil:
+		v.logger.Error("Failed to validate token: ", err)
+		abortUnauthenticated(c, ProblemInvalidToken, "Invalid token")
+		return verifiedToken{}, false
+	default:
+		claims, err := extractClaims(idToken)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "could not authenticate user"})
-			return
+			v.logger.Error("Failed to parse claims: ", err)
+			abortUnauthenticated(c, ProblemInvalidToken, "Invalid token claims")
+			return verifiedToken{}, false
+		}
+		if introspect {
+			if _, ok := v.introspectToken(c, tokenString); !ok {
+				return verifiedToken{}, false
+			}
 		}
+		token = verifiedToken{subject: idToken.Subject, expiresAt: idToken.Expiry, issuedAt: idToken.IssuedAt, claims: claims}
+	}
+
+	var claimErr *TokenClaimError
+	if errors.As(checkAudiences(token.claims, v.allowedAudiences(c)), &claimErr) {
+		v.abortWithClaimError(c, claimErr)
+		return verifiedToken{}, false
+	}
+	return token, true
+}
+
+// introspectToken checks the token with the introspection endpoint and returns the claims of the response.
+// Inactive tokens are answered with 401, a failed introspection with 503.
+func (v *Verifier) introspectToken(c *gin.Context, tokenString string) (verifiedToken, bool) {
+	active, claims, err := v.introspector.introspect(c.Request.Context(), tokenString)
+	if err != nil {
+		v.logger.Error("Failed to introspect token: ", err)
+		_ = c.Error(err)
+		abortWithProblem(c, http.StatusServiceUnavailable, ProblemIntrospectionFailed, "could not check token")
+		return verifiedToken{}, false
+	}
+	if !active {
+		v.logger.Warn("Token is not active according to the introspection endpoint")
+		abortUnauthenticated(c, ProblemInvalidToken, "Token is not active")
+		return verifiedToken{}, false
+	}
+
+	subject, _ := claims["sub"].(string)
+	return verifiedToken{
+		subject:   subject,
+		expiresAt: unixClaim(claims, "exp"),
+		issuedAt:  unixClaim(claims, "iat"),
+		claims:    claims,
+	}, true
+}
 
-		// DEPRECATED: Leave this for backward compatibility
-		// Store the extracted roles in the context
-		c.Set("userRoles", userRoles)
-		c.Set("userID", userID)
-		c.Set("userEmail", userEmail)
-		c.Set("matriculationNumber", matriculationNumber)
-		c.Set("universityLogin", universityLogin)
-		c.Set("firstName", firstName)
-		c.Set("lastName", lastName)
-
-		SetTokenUser(c, TokenUser{
-			Roles:               userRoles,
-			ID:                  userID,
-			Email:               userEmail,
-			MatriculationNumber: matriculationNumber,
-			UniversityLogin:     universityLogin,
-			FirstName:           firstName,
-			LastName:            lastName,
-		})
+// isJWT reports whether the token has the three segments of a compact JWS.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func unixClaim(claims map[string]interface{}, key string) time.Time {
+	if value, ok := claims[key].(float64); ok {
+		return time.Unix(int64(value), 0)
 	}
+	return time.Time{}
 }
 
 // extractBearerToken retrieves and validates the Bearer token from the request's Authorization header.
@@ -140,39 +239,28 @@ func checkAudience(claims map[string]interface{}, expectedClientID string) bool
 	return false
 }
 
-func checkKeycloakRoles(claims map[string]interface{}) (map[string]bool, error) {
+// checkKeycloakRoles merges the roles of all configured role sources and records the sources of each role.
+func (v *Verifier) checkKeycloakRoles(claims map[string]interface{}) (map[string]bool, map[string][]string, error) {
 	userRoles := make(map[string]bool)
-	if !checkAudience(claims, KeycloakTokenVerifierSingleton.ClientID) {
-		log.Debug("No keycloak roles found for ClientID")
-		return userRoles, nil
-	}
-
-	// user has Prompt keycloak roles
-	resourceAccess, err := extractResourceAccess(claims)
-	if err != nil {
-		log.Error("Failed to extract resource access: ", err)
-		return nil, errors.New("could not authenticate user")
-	}
-
-	rolesInterface, ok := resourceAccess[KeycloakTokenVerifierSingleton.ClientID].(map[string]interface{})["roles"]
-	if !ok {
-		log.Error("Failed to extract roles from resource access")
-		return nil, errors.New("could not authenticate user")
-	}
-
-	roles, ok := rolesInterface.([]interface{})
-	if !ok {
-		log.Error("Roles are not in expected format")
-		return nil, errors.New("could not authenticate user")
-	}
+	roleOrigins := make(map[string][]string)
+	for _, source := range v.config.roleSources() {
+		roles, err := source.roles(claims)
+		if err != nil {
+			v.logger.Error("Failed to extract roles from ", source, ": ", err)
+			return nil, nil, errors.New("could not authenticate user")
+		}
+		if len(roles) == 0 {
+			v.logger.Debug("No keycloak roles found in ", source)
+		}
 
-	// Convert roles to map[string]bool for easier downstream usage
-	for _, role := range roles {
-		if roleStr, ok := role.(string); ok {
-			userRoles[roleStr] = true
+		// Convert roles to map[string]bool for easier downstream usage
+		for _, role := range roles {
+			role = source.Prefix + role
+			userRoles[role] = true
+			roleOrigins[role] = append(roleOrigins[role], source.String())
 		}
 	}
-	return userRoles, nil
+	return userRoles, roleOrigins, nil
 }
 
 // extractResourceAccess retrieves the "resource_access" claim, which contains role information.
@@ -184,10 +272,26 @@ func extractResourceAccess(claims map[string]interface{}) (map[string]interface{
 	return resourceAccess, nil
 }
 
-func checkAuthorizedParty(claims map[string]interface{}, expectedAuthorizedParty string) bool {
-	azp, ok := claims["azp"]
+// extractRealmRoles retrieves the roles of the "realm_access" claim.
+func extractRealmRoles(claims map[string]interface{}) []string {
+	realmAccess, ok := claims["realm_access"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	roles, _ := realmAccess["roles"].([]interface{})
+	realmRoles := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if roleStr, ok := role.(string); ok {
+			realmRoles = append(realmRoles, roleStr)
+		}
+	}
+	return realmRoles
+}
+
+func checkAuthorizedParty(claims map[string]interface{}, authorizedParties []string) bool {
+	azp, ok := claims["azp"].(string)
 	if !ok {
 		return false
 	}
-	return azp == expectedAuthorizedParty
+	return slices.Contains(authorizedParties, azp)
 }
@@ -0,0 +1,145 @@
+package keycloakTokenVerifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+)
+
+func TestCheckAuthorizedParty(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		allowed []string
+		want    bool
+	}{
+		{"missing azp", map[string]interface{}{}, []string{"prompt-client"}, false},
+		{"azp not a string", map[string]interface{}{"azp": 42}, []string{"prompt-client"}, false},
+		{"single allowed party", map[string]interface{}{"azp": "prompt-client"}, []string{"prompt-client"}, true},
+		{"one of multiple allowed parties", map[string]interface{}{"azp": "prompt-cli"}, []string{"prompt-client", "prompt-cli"}, true},
+		{"unknown party", map[string]interface{}{"azp": "other"}, []string{"prompt-client", "prompt-cli"}, false},
+		{"no allowed parties", map[string]interface{}{"azp": "prompt-client"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := checkAuthorizedParty(tt.claims, tt.allowed)
+			if got != tt.want {
+				t.Errorf("checkAuthorizedParty(%v, %v) = %v; want %v", tt.claims, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifier_AuthenticationMiddleware_EndToEnd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	otherRealm := keycloakTesting.NewServer("prompt")
+	t.Cleanup(otherRealm.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid")
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/admin", v.AuthenticationMiddleware(PromptAdmin), func(c *gin.Context) {
+		tokenUser, _ := GetTokenUser(c)
+		c.JSON(http.StatusOK, gin.H{"id": tokenUser.ID, "email": tokenUser.Email})
+	})
+
+	tests := []struct {
+		name       string
+		authHeader string
+		wantStatus int
+		wantCode   string
+	}{
+		{"missing token", "", http.StatusUnauthorized, ProblemUnauthenticated},
+		{"admin", kc.Token().Subject("admin").Email("admin@tum.de").Roles(PromptAdmin).BearerHeader(), http.StatusOK, ""},
+		{"without role", kc.Token().BearerHeader(), http.StatusForbidden, ProblemForbidden},
+		{"other client role", kc.Token().ClientRoles("other-client", PromptAdmin).BearerHeader(), http.StatusForbidden, ProblemForbidden},
+		{"wrong authorized party", kc.Token().Roles(PromptAdmin).AuthorizedParty("other").BearerHeader(), http.StatusUnauthorized, ProblemInvalidAuthorizedParty},
+		{"expired", kc.Token().Roles(PromptAdmin).ExpiresAt(time.Now().Add(-time.Minute)).BearerHeader(), http.StatusUnauthorized, ProblemInvalidToken},
+		{"foreign signing key", otherRealm.Token().Claim("iss", kc.Issuer()).Roles(PromptAdmin).BearerHeader(), http.StatusUnauthorized, ProblemInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d; want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+
+			if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, ProblemContentType) {
+				t.Errorf("Content-Type = %q; want %q", contentType, ProblemContentType)
+			}
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid problem body %s: %v", rec.Body.String(), err)
+			}
+			if problem.Code != tt.wantCode || problem.Status != tt.wantStatus {
+				t.Errorf("problem = %+v; want code %q and status %d", problem, tt.wantCode, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusForbidden && !slices.Equal(problem.RequiredRoles, []string{PromptAdmin}) {
+				t.Errorf("requiredRoles = %v; want [%s]", problem.RequiredRoles, PromptAdmin)
+			}
+		})
+	}
+}
+
+func TestVerifier_KeycloakMiddleware_StandardClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid")
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	var tokenUser TokenUser
+	router := gin.New()
+	router.GET("/", v.KeycloakMiddleware(), func(c *gin.Context) {
+		tokenUser, _ = GetTokenUser(c)
+	})
+
+	expiresAt := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", kc.Token().
+		SessionID("session-1").
+		PreferredUsername("ge42abc").
+		RealmRoles("offline_access", "uma_authorization").
+		ExpiresAt(expiresAt).
+		Claim("locale", "de").
+		BearerHeader())
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !tokenUser.ExpiresAt.Equal(expiresAt) || tokenUser.IssuedAt.IsZero() {
+		t.Errorf("ExpiresAt = %v, IssuedAt = %v; want %v and a non-zero time", tokenUser.ExpiresAt, tokenUser.IssuedAt, expiresAt)
+	}
+	if tokenUser.SessionID != "session-1" || tokenUser.PreferredUsername != "ge42abc" {
+		t.Errorf("SessionID = %q, PreferredUsername = %q", tokenUser.SessionID, tokenUser.PreferredUsername)
+	}
+	if !slices.Equal(tokenUser.RealmRoles, []string{"offline_access", "uma_authorization"}) {
+		t.Errorf("RealmRoles = %v", tokenUser.RealmRoles)
+	}
+	if locale, ok := Claim[string](tokenUser, "locale"); !ok || locale != "de" {
+		t.Errorf("Claim(locale) = %q, %v", locale, ok)
+	}
+}
@@ -0,0 +1,15 @@
+package keycloakTokenVerifierDTO
+
+import "github.com/google/uuid"
+
+type GetCoursePhasesAccessRequest struct {
+	CoursePhaseIDs []uuid.UUID `json:"coursePhaseIDs"`
+}
+
+// GetCoursePhaseAccess is the entry of a single course phase in the response of the batch endpoint,
+// which maps each requested course phase ID to its entry.
+type GetCoursePhaseAccess struct {
+	Roles             GetCourseRoles              `json:"roles"`
+	IsStudentOfCourse bool                        `json:"isStudentOfCourse"`
+	Participation     GetCoursePhaseParticipation `json:"participation"`
+}
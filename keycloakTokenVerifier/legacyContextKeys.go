@@ -0,0 +1,40 @@
+package keycloakTokenVerifier
+
+import (
+	"maps"
+
+	"github.com/gin-gonic/gin"
+)
+
+// legacyContextKeys are the untyped gin context keys the middlewares set for backward compatibility,
+// mapped to their typed replacement.
+var legacyContextKeys = map[string]string{
+	"userRoles":              "GetTokenUser(c).Roles",
+	"userID":                 "UserID(c)",
+	"userEmail":              "GetTokenUser(c).Email",
+	"matriculationNumber":    "GetTokenUser(c).MatriculationNumber",
+	"universityLogin":        "GetTokenUser(c).UniversityLogin",
+	"firstName":              "GetTokenUser(c).FirstName",
+	"lastName":               "GetTokenUser(c).LastName",
+	"isLecturer":             "GetTokenUser(c).IsLecturer",
+	"isEditor":               "GetTokenUser(c).IsEditor",
+	"customRolePrefix":       "GetTokenUser(c).CustomRolePrefix",
+	"isStudentOfCourse":      "GetTokenUser(c).IsStudentOfCourse",
+	"isStudentOfCoursePhase": "GetTokenUser(c).IsStudentOfCoursePhase",
+	"courseParticipationID":  "CourseParticipationID(c)",
+}
+
+// LegacyContextKeys returns the untyped gin context keys the middlewares set for backward compatibility,
+// mapped to their typed replacement, e.g. "userRoles" to "GetTokenUser(c).Roles". Turn them off with
+// WithLegacyContextKeys(false) once all handlers use the replacements; the analyzer in tools/legacyKeys
+// finds the remaining usages. The returned map is a copy.
+func LegacyContextKeys() map[string]string {
+	return maps.Clone(legacyContextKeys)
+}
+
+// setLegacyContextKey sets one of the legacy context keys, unless they are turned off.
+func (v *Verifier) setLegacyContextKey(c *gin.Context, key string, value interface{}) {
+	if v.config.legacyContextKeys {
+		c.Set(key, value)
+	}
+}
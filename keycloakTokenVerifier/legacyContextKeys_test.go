@@ -0,0 +1,57 @@
+package keycloakTokenVerifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+)
+
+func TestWithLegacyContextKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{"enabled by default", true},
+		{"turned off", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var opts []Option
+			if !tt.enabled {
+				opts = append(opts, WithLegacyContextKeys(false))
+			}
+			v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid", opts...)
+			if err != nil {
+				t.Fatalf("NewVerifier() error = %v", err)
+			}
+			t.Cleanup(v.Close)
+
+			router := gin.New()
+			router.GET("/me", v.AuthenticationMiddleware(PromptAdmin), func(c *gin.Context) {
+				_, hasRoles := c.Get("userRoles")
+				_, hasUserID := c.Get("userID")
+				if hasRoles != tt.enabled || hasUserID != tt.enabled || UserID(c) == "" {
+					c.Status(http.StatusInternalServerError)
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", kc.Token().Roles(PromptAdmin).BearerHeader())
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d; want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,49 +1,123 @@
 package keycloakTokenVerifier
 
 import (
+	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/ls1intum/prompt-sdk/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	defaultClientID         = "prompt-server"
+	defaultAuthorizedParty  = "prompt-client"
+	defaultRequestTimeout   = 10 * time.Second
+	defaultDiscoveryTimeout = 30 * time.Second
+)
+
+var defaultCoursePhaseIDExtractor = CoursePhaseIDFromParam("coursePhaseID")
+
+// KeycloakTokenVerifier holds the configuration of a Verifier.
 type KeycloakTokenVerifier struct {
-	KeycloakURL             url.URL
-	Realm                   string
-	ClientID                string
-	expectedAuthorizedParty string
-	CoreURL                 url.URL
+	KeycloakURL url.URL
+	Realm       string
+	ClientID    string
+	CoreURL     url.URL
+
+	authorizedParties []string
+	audiences         []string
+	httpClient        *http.Client
+	requestTimeout    time.Duration
+	discoveryTimeout  time.Duration
+	logger            log.FieldLogger
+	coursePhaseCache  CoursePhaseCache
+
+	coursePhaseIDExtractor CoursePhaseIDExtractor
+	configuredRoleSources  []RoleSource
+	introspection          *IntrospectionConfig
+	lazyDiscovery          bool
+	jwksRefreshInterval    time.Duration
+	verifierMetrics        VerifierMetrics
+	legacyContextKeys      bool
 }
 
+// KeycloakTokenVerifierSingleton is the configuration of the default verifier.
 var KeycloakTokenVerifierSingleton *KeycloakTokenVerifier
 
-func InitKeycloakTokenVerifier(KeycloakURL, Realm, CoreURL string) error {
+// InitKeycloakTokenVerifier initializes the default verifier used by the package-level middlewares.
+// A previously initialized default verifier is closed.
+// Without options, the client ID "prompt-server" and the authorized party "prompt-client" are expected.
+func InitKeycloakTokenVerifier(KeycloakURL, Realm, CoreURL string, opts ...Option) error {
+	v, err := NewVerifier(KeycloakURL, Realm, CoreURL, opts...)
+	if err != nil {
+		return err
+	}
+	if v.config.coursePhaseCache == nil {
+		v.setCoursePhaseCache(getCoursePhaseCache())
+	}
+
+	KeycloakTokenVerifierSingleton = v.config
+	if previous := defaultVerifier.Swap(v); previous != nil {
+		previous.Close()
+	}
+	return nil
+}
+
+func newConfig(KeycloakURL, Realm, CoreURL string, opts ...Option) (*KeycloakTokenVerifier, error) {
 	// Parse the Keycloak URL
 	keycloakURL, err := url.Parse(KeycloakURL)
 	if err != nil {
 		log.Error("Failed to parse Keycloak URL: ", err)
-		return err
+		return nil, err
 	}
 
 	// Parse the Core URL
 	coreURL, err := url.Parse(CoreURL)
 	if err != nil {
 		log.Error("Failed to parse Core URL: ", err)
-		return err
+		return nil, err
 	}
 
-	KeycloakTokenVerifierSingleton = &KeycloakTokenVerifier{
-		KeycloakURL:             *keycloakURL,
-		Realm:                   Realm,
-		ClientID:                "prompt-server",
-		expectedAuthorizedParty: "prompt-client",
-		CoreURL:                 *coreURL,
+	config := &KeycloakTokenVerifier{
+		KeycloakURL:            *keycloakURL,
+		Realm:                  Realm,
+		ClientID:               defaultClientID,
+		CoreURL:                *coreURL,
+		authorizedParties:      []string{defaultAuthorizedParty},
+		requestTimeout:         defaultRequestTimeout,
+		discoveryTimeout:       defaultDiscoveryTimeout,
+		legacyContextKeys:      true,
+		logger:                 log.StandardLogger(),
+		coursePhaseIDExtractor: defaultCoursePhaseIDExtractor,
 	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config, nil
+}
 
-	// init the middleware
-	err = InitKeycloakVerifier()
-	if err != nil {
-		log.Error("Failed to initialize keycloak verifier: ", err)
-		return err
+// keycloakHTTPClient returns the HTTP client for the OIDC discovery, the JWKS and the token introspection.
+// Unless WithHTTPClient is used, it is not the shared SDK client, so that failing core requests cannot open
+// a circuit breaker that blocks the key refreshes or rejects every opaque token. It has no circuit breaker,
+// as the discovery retries and the key refreshes are rate-limited and introspection results are cached.
+func (k *KeycloakTokenVerifier) keycloakHTTPClient() *http.Client {
+	if k.httpClient != nil {
+		return k.httpClient
 	}
-	return nil
+	return utils.NewHTTPClient(utils.HTTPClientConfig{BreakerThreshold: -1})
+}
+
+// coreHTTPClient returns the HTTP client used for requests to the core,
+// bounded by the configured request timeout.
+func (k *KeycloakTokenVerifier) coreHTTPClient() *http.Client {
+	base := k.httpClient
+	if base == nil {
+		base = utils.HTTPClient()
+	}
+	client := *base
+	if k.requestTimeout > 0 {
+		client.Timeout = k.requestTimeout
+	}
+	return &client
 }
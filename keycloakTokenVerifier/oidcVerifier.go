@@ -2,31 +2,244 @@ package keycloakTokenVerifier
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 )
 
-// Global verifier, initialized at application start-up
-var verifier *oidc.IDTokenVerifier
+const (
+	initialDiscoveryRetryDelay  = time.Second
+	maxDiscoveryRetryDelay      = time.Minute
+	discoveryRetryBackoffFactor = 2
+)
+
+// ErrVerifierNotReady is returned while the OIDC provider of the realm has not been discovered, see WithLazyDiscovery.
+var ErrVerifierNotReady = errors.New("keycloak provider not discovered yet")
+
+// VerifierMetrics are hooks to export metrics of the provider discovery and the signing keys. All hooks are optional.
+type VerifierMetrics struct {
+	// OnDiscovery is called after every discovery attempt with its error, nil on success.
+	OnDiscovery func(err error)
+	// OnKeyRefresh is called after every JWKS refresh with the key IDs of the realm or the error.
+	OnKeyRefresh func(keyIDs []string, err error)
+	// OnKeySeen is called with the key ID of every token with a valid signature.
+	OnKeySeen func(keyID string)
+	// OnUnknownKeyID is called for every token signed with a key ID that is not in the JWKS, even after a refresh.
+	OnUnknownKeyID func(keyID string)
+}
+
+// VerifierStatus is a snapshot of the OIDC provider and the signing keys of a verifier, e.g. for a readiness probe.
+type VerifierStatus struct {
+	// Ready is true once the provider is discovered and signing keys are loaded, see Verifier.Healthy.
+	Ready                bool              `json:"ready"`
+	Issuer               string            `json:"issuer,omitempty"`
+	DiscoveredAt         time.Time         `json:"discoveredAt"`
+	DiscoveryAttempts    int               `json:"discoveryAttempts"`
+	LastDiscoveryError   string            `json:"lastDiscoveryError,omitempty"`
+	KeyIDs               []string          `json:"keyIDs"`
+	KeysRefreshedAt      time.Time         `json:"keysRefreshedAt"`
+	LastKeyRefreshError  string            `json:"lastKeyRefreshError,omitempty"`
+	KeyIDsSeen           map[string]uint64 `json:"keyIDsSeen"`
+	UnknownKeyIDFailures uint64            `json:"unknownKeyIDFailures"`
+}
+
+// oidcProvider discovers the OIDC provider of the realm, retrying in the background if requested,
+// and refreshes its signing keys periodically.
+type oidcProvider struct {
+	config     *KeycloakTokenVerifier
+	httpClient *http.Client
+
+	verifier atomic.Pointer[oidc.IDTokenVerifier]
+	keys     atomic.Pointer[keySet]
+
+	mu                sync.Mutex
+	issuer            string
+	discoveredAt      time.Time
+	attempts          int
+	lastDiscoveryErr  error
+	stop              chan struct{}
+	stopOnce          sync.Once
+	backgroundStopped chan struct{}
+}
+
+// newOIDCProvider discovers the provider. The background goroutine for the discovery retries and the key refreshes
+// is only started with lazy discovery or a JWKS refresh interval; unless lazy discovery is enabled, a failed
+// discovery is returned.
+func newOIDCProvider(config *KeycloakTokenVerifier) (*oidcProvider, error) {
+	p := &oidcProvider{
+		config:            config,
+		httpClient:        config.keycloakHTTPClient(),
+		stop:              make(chan struct{}),
+		backgroundStopped: make(chan struct{}),
+	}
+	if err := p.discover(); err != nil && !config.lazyDiscovery {
+		return nil, err
+	} else if err != nil {
+		config.logger.Warn("Keycloak discovery failed, retrying in the background: ", err)
+	}
+
+	if config.lazyDiscovery || config.jwksRefreshInterval > 0 {
+		go p.runBackground()
+	} else {
+		close(p.backgroundStopped)
+	}
+	return p, nil
+}
 
+// InitKeycloakVerifier repeats the OIDC provider discovery of the default verifier.
 func InitKeycloakVerifier() error {
+	v := defaultVerifier.Load()
+	if v == nil || KeycloakTokenVerifierSingleton == nil {
+		return errors.New("keycloak token verifier not initialized")
+	}
+	return v.provider.discover()
+}
+
+// verify verifies the signature and the standard claims of the token.
+func (p *oidcProvider) verify(ctx context.Context, token string) (*oidc.IDToken, error) {
+	verifier := p.verifier.Load()
+	if verifier == nil {
+		return nil, ErrVerifierNotReady
+	}
+	return verifier.Verify(ctx, token)
+}
+
+// discover loads the discovery document and the signing keys of the realm.
+func (p *oidcProvider) discover() error {
 	ctx := context.Background()
+	if p.config.discoveryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.discoveryTimeout)
+		defer cancel()
+	}
+
+	issuer, verifier, keys, err := p.newVerifier(ctx)
+
+	p.mu.Lock()
+	p.attempts++
+	p.lastDiscoveryErr = err
+	if err == nil {
+		p.issuer = issuer
+		p.discoveredAt = time.Now()
+		p.keys.Store(keys)
+		p.verifier.Store(verifier)
+	}
+	p.mu.Unlock()
+
+	if p.config.verifierMetrics.OnDiscovery != nil {
+		p.config.verifierMetrics.OnDiscovery(err)
+	}
+	return err
+}
+
+func (p *oidcProvider) newVerifier(ctx context.Context) (string, *oidc.IDTokenVerifier, *keySet, error) {
+	ctx = oidc.ClientContext(ctx, p.httpClient)
 
 	// Construct the provider URL. Keycloak hosts OIDC metadata at:
 	//   {BaseURL}/realms/{Realm}/.well-known/openid-configuration
-	providerURL := KeycloakTokenVerifierSingleton.KeycloakURL.JoinPath("realms", KeycloakTokenVerifierSingleton.Realm)
+	providerURL := p.config.KeycloakURL.JoinPath("realms", p.config.Realm)
 
 	provider, err := oidc.NewProvider(ctx, providerURL.String())
 	if err != nil {
-		return fmt.Errorf("failed to create new OIDC provider: %w", err)
+		return "", nil, nil, fmt.Errorf("failed to create new OIDC provider: %w", err)
+	}
+
+	var metadata struct {
+		Issuer     string   `json:"issuer"`
+		JWKSURL    string   `json:"jwks_uri"`
+		Algorithms []string `json:"id_token_signing_alg_values_supported"`
+	}
+	if err := provider.Claims(&metadata); err != nil {
+		return "", nil, nil, fmt.Errorf("failed to decode provider metadata: %w", err)
+	}
+
+	keys := newKeySet(metadata.JWKSURL, p.httpClient, p.config.verifierMetrics)
+	if err := keys.refresh(ctx); err != nil {
+		// the keys are requested again for the first token
+		p.config.logger.Warn("Failed to load the signing keys of the realm: ", err)
 	}
 
 	// Configure the verifier with the expected client ID (audience)
-	config := &oidc.Config{
-		SkipClientIDCheck: true, // otherwise students cannot apply to courses
+	oidcConfig := &oidc.Config{
+		SkipClientIDCheck:    true, // otherwise students cannot apply to courses
+		SupportedSigningAlgs: metadata.Algorithms,
+	}
+	return metadata.Issuer, oidc.NewVerifier(metadata.Issuer, keys, oidcConfig), keys, nil
+}
+
+// runBackground retries the discovery until it succeeds and then refreshes the keys periodically, until close.
+func (p *oidcProvider) runBackground() {
+	defer close(p.backgroundStopped)
+
+	delay := initialDiscoveryRetryDelay
+	for p.verifier.Load() == nil {
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(delay):
+		}
+		if err := p.discover(); err != nil {
+			p.config.logger.Warn("Keycloak discovery failed: ", err)
+			delay = min(delay*discoveryRetryBackoffFactor, maxDiscoveryRetryDelay)
+		}
 	}
 
-	verifier = provider.Verifier(config)
-	return nil
+	if p.config.jwksRefreshInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.config.jwksRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.refreshKeys()
+		}
+	}
+}
+
+func (p *oidcProvider) refreshKeys() {
+	keys := p.keys.Load()
+	if keys == nil {
+		return
+	}
+	ctx := context.Background()
+	if p.config.discoveryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.discoveryTimeout)
+		defer cancel()
+	}
+	if err := keys.refresh(ctx); err != nil {
+		p.config.logger.Warn("Failed to refresh the signing keys of the realm: ", err)
+	}
+}
+
+func (p *oidcProvider) close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.backgroundStopped
+}
+
+func (p *oidcProvider) status() VerifierStatus {
+	p.mu.Lock()
+	status := VerifierStatus{
+		Issuer:            p.issuer,
+		DiscoveredAt:      p.discoveredAt,
+		DiscoveryAttempts: p.attempts,
+	}
+	if p.lastDiscoveryErr != nil {
+		status.LastDiscoveryError = p.lastDiscoveryErr.Error()
+	}
+	p.mu.Unlock()
+
+	if keys := p.keys.Load(); keys != nil {
+		keys.status(&status)
+	}
+	status.Ready = p.verifier.Load() != nil && len(status.KeyIDs) > 0
+	return status
 }
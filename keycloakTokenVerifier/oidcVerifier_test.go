@@ -0,0 +1,162 @@
+package keycloakTokenVerifier
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+)
+
+func newStatusRouter(v *Verifier) *gin.Engine {
+	router := gin.New()
+	router.GET("/", v.KeycloakMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within 5s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestVerifier_KeyRotation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	var mu sync.Mutex
+	var seen, unknown []string
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid", WithVerifierMetrics(VerifierMetrics{
+		OnKeySeen:      func(keyID string) { mu.Lock(); seen = append(seen, keyID); mu.Unlock() },
+		OnUnknownKeyID: func(keyID string) { mu.Lock(); unknown = append(unknown, keyID); mu.Unlock() },
+	}))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+	router := newStatusRouter(v)
+
+	oldKeyID := kc.KeyID()
+	oldToken := kc.Token().BearerHeader()
+	kc.RotateKey(false)
+	newKeyID := kc.KeyID()
+
+	for _, token := range []string{oldToken, kc.Token().BearerHeader()} {
+		if rec := serve(router, "/", token); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d; want %d after key rotation", rec.Code, http.StatusOK)
+		}
+	}
+
+	otherRealm := keycloakTesting.NewServer("prompt")
+	t.Cleanup(otherRealm.Close)
+	foreignToken := otherRealm.Token().Claim("iss", kc.Issuer()).BearerHeader()
+	if rec := serve(router, "/", foreignToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d; want %d for an unknown key ID", rec.Code, http.StatusUnauthorized)
+	}
+
+	status := v.Status()
+	if !status.Ready || !slices.Equal(status.KeyIDs, keyIDsOf(oldKeyID, newKeyID)) {
+		t.Errorf("status = %+v; want ready with keys %s and %s", status, oldKeyID, newKeyID)
+	}
+	if status.KeyIDsSeen[oldKeyID] != 1 || status.KeyIDsSeen[newKeyID] != 1 || status.UnknownKeyIDFailures != 1 {
+		t.Errorf("KeyIDsSeen = %v, UnknownKeyIDFailures = %d", status.KeyIDsSeen, status.UnknownKeyIDFailures)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 2 || !slices.Equal(unknown, []string{otherRealm.KeyID()}) {
+		t.Errorf("metrics seen = %v, unknown = %v", seen, unknown)
+	}
+}
+
+func keyIDsOf(ids ...string) []string {
+	slices.Sort(ids)
+	return ids
+}
+
+func TestVerifier_BackgroundKeyRefresh(t *testing.T) {
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid", WithJWKSRefreshInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+
+	kc.RotateKey(true)
+	waitFor(t, func() bool { return slices.Equal(v.Status().KeyIDs, []string{kc.KeyID()}) })
+}
+
+func TestVerifier_LazyDiscovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	kc.SetUnavailable(true)
+
+	if _, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid"); err == nil {
+		t.Fatalf("NewVerifier() without lazy discovery succeeded while Keycloak is unavailable")
+	}
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid", WithLazyDiscovery())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+	router := newStatusRouter(v)
+
+	if v.Healthy() || v.Status().LastDiscoveryError == "" {
+		t.Errorf("status = %+v; want unhealthy with discovery error", v.Status())
+	}
+	if rec := serve(router, "/", kc.Token().BearerHeader()); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d; want %d before discovery", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	kc.SetUnavailable(false)
+	waitFor(t, v.Healthy)
+	if rec := serve(router, "/", kc.Token().BearerHeader()); rec.Code != http.StatusOK {
+		t.Errorf("status = %d; want %d after discovery", rec.Code, http.StatusOK)
+	}
+	if status := v.Status(); status.Issuer != kc.Issuer() || status.DiscoveryAttempts < 2 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestVerifier_BackgroundGoroutine(t *testing.T) {
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid")
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	select {
+	case <-v.provider.backgroundStopped:
+	default:
+		t.Errorf("background goroutine started without lazy discovery or JWKS refresh interval")
+	}
+
+	if err := InitKeycloakTokenVerifier(kc.URL(), kc.Realm, "http://core.invalid", WithJWKSRefreshInterval(time.Hour)); err != nil {
+		t.Fatalf("InitKeycloakTokenVerifier() error = %v", err)
+	}
+	previous := DefaultVerifier()
+	if err := InitKeycloakTokenVerifier(kc.URL(), kc.Realm, "http://core.invalid", WithJWKSRefreshInterval(time.Hour)); err != nil {
+		t.Fatalf("InitKeycloakTokenVerifier() error = %v", err)
+	}
+	t.Cleanup(DefaultVerifier().Close)
+	select {
+	case <-previous.provider.backgroundStopped:
+	case <-time.After(time.Second):
+		t.Errorf("previous default verifier not closed by InitKeycloakTokenVerifier")
+	}
+}
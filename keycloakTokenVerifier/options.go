@@ -0,0 +1,128 @@
+package keycloakTokenVerifier
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Option configures the KeycloakTokenVerifier in InitKeycloakTokenVerifier.
+type Option func(*KeycloakTokenVerifier)
+
+// WithClientID sets the Keycloak client whose roles are read from the token (default "prompt-server").
+func WithClientID(clientID string) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.ClientID = clientID
+	}
+}
+
+// WithAuthorizedParties sets the accepted values of the "azp" claim (default "prompt-client").
+// Route groups can override it with UseAuthorizedParties.
+func WithAuthorizedParties(authorizedParties ...string) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.authorizedParties = authorizedParties
+	}
+}
+
+// WithAudiences requires one of the audiences in the aud claim of every token (default none, the audience is not checked,
+// so that students can apply to courses). Route groups can override it with UseAudiences.
+func WithAudiences(audiences ...string) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.audiences = audiences
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for the OIDC discovery, the JWKS and the core requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.httpClient = client
+	}
+}
+
+// WithRequestTimeout sets the timeout of a single request to the core (default 10s).
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.requestTimeout = timeout
+	}
+}
+
+// WithDiscoveryTimeout sets the timeout of the OIDC provider discovery at start-up (default 30s).
+func WithDiscoveryTimeout(timeout time.Duration) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.discoveryTimeout = timeout
+	}
+}
+
+// WithLazyDiscovery lets NewVerifier succeed even if Keycloak is not reachable at start-up.
+// The discovery is then retried in the background with exponential backoff (1s up to 1min)
+// and requests are answered with 503 until it succeeds, see Verifier.Healthy.
+func WithLazyDiscovery() Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.lazyDiscovery = true
+	}
+}
+
+// WithJWKSRefreshInterval refreshes the signing keys in the background every interval, e.g. 10min.
+// By default there is no background refresh; tokens with an unknown key ID always trigger a refresh.
+func WithJWKSRefreshInterval(interval time.Duration) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.jwksRefreshInterval = interval
+	}
+}
+
+// WithVerifierMetrics sets hooks to export metrics of the discovery and the signing keys.
+func WithVerifierMetrics(metrics VerifierMetrics) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.verifierMetrics = metrics
+	}
+}
+
+// WithLogger sets the logger used by the middlewares and the core requests.
+func WithLogger(logger log.FieldLogger) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.logger = logger
+	}
+}
+
+// WithCoursePhaseCache sets the cache for course phase role mappings and student checks.
+func WithCoursePhaseCache(cache CoursePhaseCache) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.coursePhaseCache = cache
+	}
+}
+
+// WithCoursePhaseIDExtractor sets how the course phase ID is read from a request (default CoursePhaseIDFromParam("coursePhaseID")).
+// Single routes can override it with UseCoursePhaseIDExtractor.
+func WithCoursePhaseIDExtractor(extractor CoursePhaseIDExtractor) Option {
+	return func(k *KeycloakTokenVerifier) {
+		if extractor == nil {
+			extractor = defaultCoursePhaseIDExtractor
+		}
+		k.coursePhaseIDExtractor = extractor
+	}
+}
+
+// WithRoleSources sets the claims TokenUser.Roles are merged from (default ClientRoleSource of the ClientID), e.g.
+//
+//	WithRoleSources(ClientRoleSource("prompt-server"), RealmRoleSource(), ClientRoleSource("ios-server").WithPrefix("ios-"))
+func WithRoleSources(sources ...RoleSource) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.configuredRoleSources = sources
+	}
+}
+
+// WithIntrospection configures the token introspection used by the routes after UseIntrospection.
+func WithIntrospection(config IntrospectionConfig) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.introspection = &config
+	}
+}
+
+// WithLegacyContextKeys turns the deprecated untyped gin context keys such as "userRoles" on or off (default on).
+// Handlers should read the TokenUser instead, see LegacyContextKeys().
+func WithLegacyContextKeys(enabled bool) Option {
+	return func(k *KeycloakTokenVerifier) {
+		k.legacyContextKeys = enabled
+	}
+}
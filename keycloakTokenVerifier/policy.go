@@ -0,0 +1,179 @@
+package keycloakTokenVerifier
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy is an authorization rule built with All, Any, Not, Role and Predicate, e.g.
+//
+//	All(Role(CourseEditor), Predicate(isOwner))
+//	All(Role(CourseStudent), Predicate(func(u TokenUser, _ *gin.Context) bool { return u.IsStudentOfCoursePhase }))
+//	Any(Role("Tutor"), Role(PromptLecturer))
+//
+// Policies are evaluated from left to right and stop as soon as the result is known.
+// Course phase roles are requested from the core only when a Role needs them, at most once per request.
+type Policy interface {
+	allows(e *policyEvaluation) bool
+	// roles returns the roles the policy checks for, reported as requiredRoles if access is denied.
+	roles() []string
+}
+
+type policy struct {
+	evaluate func(e *policyEvaluation) bool
+	checked  []string
+}
+
+func (p policy) allows(e *policyEvaluation) bool {
+	return p.evaluate(e)
+}
+
+func (p policy) roles() []string {
+	return p.checked
+}
+
+// rolesOf collects the roles checked by the policies without duplicates.
+func rolesOf(policies []Policy) []string {
+	var roles []string
+	for _, p := range policies {
+		for _, role := range p.roles() {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+// policyEvaluation holds the state of evaluating a policy for one request.
+type policyEvaluation struct {
+	v               *Verifier
+	c               *gin.Context
+	rolesResolved   bool
+	studentResolved bool
+}
+
+func (e *policyEvaluation) tokenUser() TokenUser {
+	tokenUser, _ := GetTokenUser(e.c)
+	return tokenUser
+}
+
+// resolveCourseRoles runs the lecturer and editor role step of the AuthenticationMiddleware once.
+func (e *policyEvaluation) resolveCourseRoles() bool {
+	if !e.rolesResolved {
+		e.rolesResolved = true
+		e.v.getLecturerAndEditorRole(e.c)
+	}
+	return !e.c.IsAborted()
+}
+
+// resolveStudent runs the student step of the AuthenticationMiddleware once.
+func (e *policyEvaluation) resolveStudent() bool {
+	if !e.studentResolved {
+		e.studentResolved = true
+		e.v.isStudentOfCoursePhase(e.c)
+	}
+	return !e.c.IsAborted()
+}
+
+// All allows the request if all policies allow it.
+func All(policies ...Policy) Policy {
+	return policy{checked: rolesOf(policies), evaluate: func(e *policyEvaluation) bool {
+		for _, p := range policies {
+			if !p.allows(e) || e.c.IsAborted() {
+				return false
+			}
+		}
+		return true
+	}}
+}
+
+// Any allows the request if at least one of the policies allows it.
+func Any(policies ...Policy) Policy {
+	return policy{checked: rolesOf(policies), evaluate: func(e *policyEvaluation) bool {
+		for _, p := range policies {
+			if p.allows(e) && !e.c.IsAborted() {
+				return true
+			}
+			if e.c.IsAborted() {
+				return false
+			}
+		}
+		return false
+	}}
+}
+
+// Not allows the request if the policy denies it.
+// Roles checked by Not are not reported as required.
+func Not(p Policy) Policy {
+	return policy{evaluate: func(e *policyEvaluation) bool {
+		return !p.allows(e) && !e.c.IsAborted()
+	}}
+}
+
+// Role allows the request if the user has the role, with the same role names as AuthenticationMiddleware:
+// PromptAdmin and PromptLecturer are read from the token, CourseLecturer, CourseEditor, CourseStudent
+// and custom role names are resolved for the course phase of the request.
+func Role(role string) Policy {
+	return policy{checked: []string{role}, evaluate: func(e *policyEvaluation) bool {
+		switch role {
+		case PromptAdmin, PromptLecturer:
+			return e.tokenUser().Roles[role]
+		case CourseLecturer:
+			return e.resolveCourseRoles() && e.tokenUser().IsLecturer
+		case CourseEditor:
+			return e.resolveCourseRoles() && e.tokenUser().IsEditor
+		case CourseStudent:
+			return e.resolveStudent() && e.tokenUser().IsStudentOfCourse
+		default:
+			if !e.resolveCourseRoles() {
+				return false
+			}
+			tokenUser := e.tokenUser()
+			return tokenUser.Roles[tokenUser.CustomRolePrefix+role]
+		}
+	}}
+}
+
+// Predicate allows the request if check returns true. check sees the token user with the course phase roles
+// resolved so far, e.g. IsStudentOfCoursePhase is only set after a Role(CourseStudent) evaluated before it.
+func Predicate(check func(tokenUser TokenUser, c *gin.Context) bool) Policy {
+	return policy{evaluate: func(e *policyEvaluation) bool {
+		return check(e.tokenUser(), e.c)
+	}}
+}
+
+// PolicyMiddleware is Verifier.PolicyMiddleware of the default verifier.
+func PolicyMiddleware(p Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v := loadDefaultVerifier(c); v != nil {
+			v.authorize(c, p)
+		}
+	}
+}
+
+// PolicyMiddleware validates the token like KeycloakMiddleware and grants access if the policy allows the request.
+// A denied request is answered with 403 and the roles checked by the policy.
+func (v *Verifier) PolicyMiddleware(p Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v.authorize(c, p)
+	}
+}
+
+func (v *Verifier) authorize(c *gin.Context, p Policy) {
+	v.keycloakMiddleware(c)
+	if c.IsAborted() {
+		return
+	}
+
+	allowed := p.allows(&policyEvaluation{v: v, c: c})
+	if c.IsAborted() {
+		return
+	}
+	if !allowed {
+		abortForbidden(c, p.roles())
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,156 @@
+package keycloakTokenVerifier
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+)
+
+func TestPolicyCombinators(t *testing.T) {
+	allow := Predicate(func(TokenUser, *gin.Context) bool { return true })
+	deny := Predicate(func(TokenUser, *gin.Context) bool { return false })
+	admin := Role(PromptAdmin)
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   bool
+	}{
+		{name: "all allow", policy: All(allow, admin), want: true},
+		{name: "all with one deny", policy: All(allow, deny), want: false},
+		{name: "all of nothing", policy: All(), want: true},
+		{name: "any with one allow", policy: Any(deny, admin), want: true},
+		{name: "any deny", policy: Any(deny, Role(PromptLecturer)), want: false},
+		{name: "any of nothing", policy: Any(), want: false},
+		{name: "not", policy: Not(deny), want: true},
+		{name: "nested", policy: Any(All(admin, Not(admin)), Not(Any(deny))), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			SetTokenUser(c, TokenUser{Roles: map[string]bool{PromptAdmin: true}})
+
+			// global roles are read from the token, so no verifier (and no core) is needed
+			if got := tt.policy.allows(&policyEvaluation{c: c}); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// newPolicyCore starts a core that answers the role mapping and the student requests of every course phase,
+// identifying the student by the unverified sub claim of the forwarded token. A rolesStatus other than 200 fails the role mapping.
+func newPolicyCore(t *testing.T, roles keycloakTokenVerifierDTO.GetCourseRoles, rolesStatus int, students map[string]keycloakTokenVerifierDTO.GetCoursePhaseParticipation) *httptest.Server {
+	t.Helper()
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/roles"):
+			w.WriteHeader(rolesStatus)
+			_ = json.NewEncoder(w).Encode(roles)
+		case strings.HasSuffix(r.URL.Path, "/is_student"):
+			var claims struct {
+				Subject string `json:"sub"`
+			}
+			parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+			if len(parts) == 3 {
+				payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+				_ = json.Unmarshal(payload, &claims)
+			}
+			participation, ok := students[claims.Subject]
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(participation)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(core.Close)
+	return core
+}
+
+func newPolicyVerifier(t *testing.T, kc *keycloakTesting.Server, core *httptest.Server) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(kc.URL(), kc.Realm, core.URL)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+	return v
+}
+
+func TestVerifier_PolicyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	roles := keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios25-Lecturer", CourseEditorRole: "ios25-Editor"}
+	students := map[string]keycloakTokenVerifierDTO.GetCoursePhaseParticipation{
+		"student": {IsStudentOfCoursePhase: true, CourseParticipationID: uuid.New()},
+	}
+	v := newPolicyVerifier(t, kc, newPolicyCore(t, roles, http.StatusOK, students))
+	failing := newPolicyVerifier(t, kc, newPolicyCore(t, roles, http.StatusInternalServerError, students))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	isOwner := Predicate(func(tokenUser TokenUser, c *gin.Context) bool { return c.Query("owner") == tokenUser.ID })
+	router := gin.New()
+	router.GET("/:coursePhaseID/lecturer", v.PolicyMiddleware(Role(CourseLecturer)), ok)
+	router.GET("/:coursePhaseID/editor-or-owner", v.PolicyMiddleware(Any(Role(CourseEditor), All(Role(CourseStudent), isOwner))), ok)
+	router.GET("/:coursePhaseID/not-student", v.PolicyMiddleware(All(Role(PromptLecturer), Not(Role(CourseStudent)))), ok)
+	router.GET("/:coursePhaseID/failing", failing.PolicyMiddleware(Role(CourseLecturer)), ok)
+
+	phase := "/" + uuid.NewString()
+	tests := []struct {
+		name         string
+		path         string
+		token        *keycloakTesting.TokenBuilder
+		wantStatus   int
+		wantRequired []string
+		wantProblem  string
+	}{
+		{name: "course lecturer", path: phase + "/lecturer", token: kc.Token().Subject("lecturer").Roles("ios25-Lecturer"), wantStatus: http.StatusOK},
+		{name: "no course role", path: phase + "/lecturer", token: kc.Token().Subject("someone"), wantStatus: http.StatusForbidden, wantRequired: []string{CourseLecturer}, wantProblem: ProblemForbidden},
+		{name: "no token", path: phase + "/lecturer", wantStatus: http.StatusUnauthorized, wantProblem: ProblemUnauthenticated},
+		{name: "invalid course phase ID", path: "/not-a-uuid/lecturer", token: kc.Token().Subject("lecturer").Roles("ios25-Lecturer"), wantStatus: http.StatusBadRequest, wantProblem: ProblemInvalidCoursePhaseID},
+		{name: "editor", path: phase + "/editor-or-owner", token: kc.Token().Subject("editor").Roles("ios25-Editor"), wantStatus: http.StatusOK},
+		{name: "owning student", path: phase + "/editor-or-owner?owner=student", token: kc.Token().Subject("student"), wantStatus: http.StatusOK},
+		{name: "other student", path: phase + "/editor-or-owner?owner=other", token: kc.Token().Subject("student"), wantStatus: http.StatusForbidden, wantRequired: []string{CourseEditor, CourseStudent}, wantProblem: ProblemForbidden},
+		{name: "lecturer not student", path: phase + "/not-student", token: kc.Token().Subject("lecturer").Roles(PromptLecturer), wantStatus: http.StatusOK},
+		{name: "lecturer and student", path: phase + "/not-student", token: kc.Token().Subject("student").Roles(PromptLecturer), wantStatus: http.StatusForbidden, wantRequired: []string{PromptLecturer}, wantProblem: ProblemForbidden},
+		{name: "core failure", path: phase + "/failing", token: kc.Token().Subject("lecturer").Roles("ios25-Lecturer"), wantStatus: http.StatusBadGateway, wantProblem: ProblemCoreRequestFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			authHeader := ""
+			if tt.token != nil {
+				authHeader = tt.token.BearerHeader()
+			}
+			rec := serve(router, tt.path, authHeader)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d; want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantProblem == "" {
+				return
+			}
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if problem.Code != tt.wantProblem || !slices.Equal(problem.RequiredRoles, tt.wantRequired) {
+				t.Errorf("problem = %+v; want code %q and required roles %v", problem, tt.wantProblem, tt.wantRequired)
+			}
+		})
+	}
+}
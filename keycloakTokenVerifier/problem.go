@@ -0,0 +1,68 @@
+package keycloakTokenVerifier
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the content type of the error responses of the middlewares (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// Problem codes of the error responses of the middlewares.
+const (
+	// ProblemUnauthenticated: the Authorization header is missing or not a bearer token (401).
+	ProblemUnauthenticated = "unauthenticated"
+	// ProblemInvalidToken: the token is invalid or expired (401).
+	ProblemInvalidToken = "invalid_token"
+	// ProblemInvalidAudience: the aud claim of the token contains none of the audiences allowed for the route (401).
+	ProblemInvalidAudience = "invalid_audience"
+	// ProblemInvalidAuthorizedParty: the azp claim of the token is not allowed for the route (401).
+	ProblemInvalidAuthorizedParty = "invalid_authorized_party"
+	// ProblemForbidden: the user is authenticated but lacks the required roles (403).
+	ProblemForbidden = "forbidden"
+	// ProblemInvalidCoursePhaseID: the course phase ID of the request is missing or invalid (400).
+	ProblemInvalidCoursePhaseID = "invalid_course_phase_id"
+	// ProblemCoreRequestFailed: the roles could not be resolved from the core (404 for an unknown course phase, 502 if the core failed, else 500).
+	ProblemCoreRequestFailed = "core_request_failed"
+	// ProblemIntrospectionFailed: the token could not be checked with the introspection endpoint (503).
+	ProblemIntrospectionFailed = "introspection_failed"
+	// ProblemVerifierUnavailable: the OIDC provider of the realm has not been discovered yet (503).
+	ProblemVerifierUnavailable = "verifier_unavailable"
+	// ProblemInternal: the middleware is not initialized or misconfigured (500).
+	ProblemInternal = "internal_error"
+)
+
+// Problem is the body of the error responses of the middlewares, an RFC 7807 problem with the extension members
+// code, message and requiredRoles.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Code is the machine-readable reason, one of the Problem* constants.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// RequiredRoles lists the roles of which the user needs at least one; only set for ProblemForbidden.
+	RequiredRoles []string `json:"requiredRoles,omitempty"`
+}
+
+// abortWithProblem aborts the request with a problem+json body.
+func abortWithProblem(c *gin.Context, status int, code, message string, requiredRoles ...string) {
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(status, Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Code:          code,
+		Message:       message,
+		RequiredRoles: requiredRoles,
+	})
+}
+
+func abortUnauthenticated(c *gin.Context, code, message string) {
+	abortWithProblem(c, http.StatusUnauthorized, code, message)
+}
+
+func abortForbidden(c *gin.Context, requiredRoles []string) {
+	abortWithProblem(c, http.StatusForbidden, ProblemForbidden, "missing required role", requiredRoles...)
+}
@@ -0,0 +1,80 @@
+package keycloakTokenVerifier
+
+import "errors"
+
+// RoleSource describes a claim of the token that TokenUser.Roles are read from.
+// Create it with RealmRoleSource or ClientRoleSource.
+type RoleSource struct {
+	// Client is the Keycloak client of resource_access[Client].roles, or empty for realm_access.roles.
+	Client string
+	// Prefix is prepended to every role of the source, e.g. to keep the roles of a second client apart.
+	Prefix string
+}
+
+// RealmRoleSource reads the realm roles of realm_access.roles.
+func RealmRoleSource() RoleSource {
+	return RoleSource{}
+}
+
+// ClientRoleSource reads the client roles of resource_access[clientID].roles,
+// if the client is in the audience of the token. This is the default source for the configured ClientID.
+func ClientRoleSource(clientID string) RoleSource {
+	return RoleSource{Client: clientID}
+}
+
+// WithPrefix returns a copy of the source that prepends prefix to its roles.
+func (s RoleSource) WithPrefix(prefix string) RoleSource {
+	s.Prefix = prefix
+	return s
+}
+
+// String describes the source in TokenUser.RoleOrigins, e.g. "realm" or "client:prompt-server".
+func (s RoleSource) String() string {
+	if s.Client == "" {
+		return "realm"
+	}
+	return "client:" + s.Client
+}
+
+// roles returns the roles of the source in the claims.
+func (s RoleSource) roles(claims map[string]interface{}) ([]string, error) {
+	if s.Client == "" {
+		return extractRealmRoles(claims), nil
+	}
+
+	if !checkAudience(claims, s.Client) {
+		return nil, nil
+	}
+	resourceAccess, err := extractResourceAccess(claims)
+	if err != nil {
+		return nil, err
+	}
+	client, ok := resourceAccess[s.Client].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	rolesInterface, ok := client["roles"]
+	if !ok {
+		return nil, errors.New("roles missing in resource access")
+	}
+	roles, ok := rolesInterface.([]interface{})
+	if !ok {
+		return nil, errors.New("roles are not in expected format")
+	}
+
+	clientRoles := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if roleStr, ok := role.(string); ok {
+			clientRoles = append(clientRoles, roleStr)
+		}
+	}
+	return clientRoles, nil
+}
+
+// roleSources returns the configured role sources, by default the client roles of the ClientID.
+func (k *KeycloakTokenVerifier) roleSources() []RoleSource {
+	if len(k.configuredRoleSources) == 0 {
+		return []RoleSource{ClientRoleSource(k.ClientID)}
+	}
+	return k.configuredRoleSources
+}
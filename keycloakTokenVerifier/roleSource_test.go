@@ -0,0 +1,95 @@
+package keycloakTokenVerifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+)
+
+func TestRoleSource_Roles(t *testing.T) {
+	claims := map[string]interface{}{
+		"aud":          []interface{}{"prompt-server", "ios-server"},
+		"realm_access": map[string]interface{}{"roles": []interface{}{"offline_access"}},
+		"resource_access": map[string]interface{}{
+			"prompt-server": map[string]interface{}{"roles": []interface{}{PromptAdmin}},
+			"ios-server":    map[string]interface{}{"roles": []interface{}{"Tutor"}},
+			"not-audience":  map[string]interface{}{"roles": []interface{}{"Ignored"}},
+		},
+	}
+	tests := []struct {
+		name   string
+		source RoleSource
+		want   []string
+	}{
+		{"realm roles", RealmRoleSource(), []string{"offline_access"}},
+		{"client roles", ClientRoleSource("prompt-server"), []string{PromptAdmin}},
+		{"client not in audience", ClientRoleSource("not-audience"), nil},
+		{"client without roles", ClientRoleSource("missing"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.source.roles(claims)
+			if err != nil {
+				t.Fatalf("roles() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("roles() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifier_KeycloakMiddleware_RoleSources(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid", WithRoleSources(
+		ClientRoleSource(kc.ClientID),
+		RealmRoleSource(),
+		ClientRoleSource("ios-server").WithPrefix("ios-"),
+	))
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	var tokenUser TokenUser
+	router := gin.New()
+	router.GET("/", v.KeycloakMiddleware(), func(c *gin.Context) {
+		tokenUser, _ = GetTokenUser(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", kc.Token().
+		Roles(PromptLecturer).
+		RealmRoles(PromptLecturer, "offline_access").
+		ClientRoles("ios-server", "Tutor").
+		BearerHeader())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want %d", rec.Code, http.StatusOK)
+	}
+
+	wantOrigins := map[string][]string{
+		PromptLecturer:   {"client:" + kc.ClientID, "realm"},
+		"offline_access": {"realm"},
+		"ios-Tutor":      {"client:ios-server"},
+	}
+	for role, origins := range wantOrigins {
+		if !tokenUser.Roles[role] {
+			t.Errorf("Roles[%q] = false; want true", role)
+		}
+		if !slices.Equal(tokenUser.RoleOrigins[role], origins) {
+			t.Errorf("RoleOrigins[%q] = %v; want %v", role, tokenUser.RoleOrigins[role], origins)
+		}
+	}
+	if len(tokenUser.Roles) != len(wantOrigins) {
+		t.Errorf("Roles = %v; want %d roles", tokenUser.Roles, len(wantOrigins))
+	}
+}
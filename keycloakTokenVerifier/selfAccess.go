@@ -0,0 +1,58 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TeamMembershipFunc reports whether the user is a member of the team with the given ID, e.g. by querying the module's database.
+type TeamMembershipFunc func(ctx context.Context, tokenUser TokenUser, teamID string) (bool, error)
+
+// StudentSelfAccess allows PROMPT admins and lecturers and the lecturers and editors of the course phase
+// to access every participation, and students of the course phase only their own one,
+// whose courseParticipationID is read from the route parameter participationParam:
+//
+//	router.GET("/participations/:courseParticipationID", PolicyMiddleware(StudentSelfAccess("courseParticipationID")), handler)
+func StudentSelfAccess(participationParam string) Policy {
+	return selfAccess(All(Role(CourseStudent), OwnParticipation(participationParam)))
+}
+
+// StudentTeamAccess is StudentSelfAccess for teams: students may only access the team with the ID
+// in the route parameter teamParam if isMember reports them as a member.
+func StudentTeamAccess(teamParam string, isMember TeamMembershipFunc) Policy {
+	return selfAccess(All(Role(CourseStudent), OwnTeam(teamParam, isMember)))
+}
+
+func selfAccess(student Policy) Policy {
+	return Any(Role(PromptAdmin), Role(PromptLecturer), Role(CourseLecturer), Role(CourseEditor), student)
+}
+
+// OwnParticipation allows the request if the route parameter participationParam is the courseParticipationID of the user.
+// It must be combined with a Role(CourseStudent) evaluated before it, which resolves the participation.
+func OwnParticipation(participationParam string) Policy {
+	return Predicate(func(tokenUser TokenUser, c *gin.Context) bool {
+		courseParticipationID, err := uuid.Parse(c.Param(participationParam))
+		return err == nil && tokenUser.CourseParticipationID != uuid.Nil && courseParticipationID == tokenUser.CourseParticipationID
+	})
+}
+
+// OwnTeam allows the request if isMember reports the user as a member of the team in the route parameter teamParam.
+// An error of isMember aborts the request with 500.
+func OwnTeam(teamParam string, isMember TeamMembershipFunc) Policy {
+	return Predicate(func(tokenUser TokenUser, c *gin.Context) bool {
+		teamID := c.Param(teamParam)
+		if teamID == "" {
+			return false
+		}
+		member, err := isMember(c.Request.Context(), tokenUser, teamID)
+		if err != nil {
+			_ = c.Error(err)
+			abortWithProblem(c, http.StatusInternalServerError, ProblemInternal, "could not check team membership")
+			return false
+		}
+		return member
+	})
+}
@@ -0,0 +1,70 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakTokenVerifierDTO"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+)
+
+func TestStudentSelfAccessAndTeamAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	ownParticipationID, otherParticipationID := uuid.New(), uuid.New()
+	roles := keycloakTokenVerifierDTO.GetCourseRoles{CourseLecturerRole: "ios25-Lecturer", CourseEditorRole: "ios25-Editor"}
+	v := newPolicyVerifier(t, kc, newPolicyCore(t, roles, http.StatusOK, map[string]keycloakTokenVerifierDTO.GetCoursePhaseParticipation{
+		"student": {IsStudentOfCoursePhase: true, CourseParticipationID: ownParticipationID},
+		// a student of the course without a participation in the course phase
+		"applicant": {IsStudentOfCoursePhase: false},
+	}))
+
+	isMember := func(_ context.Context, tokenUser TokenUser, teamID string) (bool, error) {
+		if teamID == "broken" {
+			return false, errors.New("database unavailable")
+		}
+		return tokenUser.ID == "student" && teamID == "own-team", nil
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.GET("/:coursePhaseID/participations/:participationID", v.PolicyMiddleware(StudentSelfAccess("participationID")), ok)
+	router.GET("/:coursePhaseID/teams/:teamID", v.PolicyMiddleware(StudentTeamAccess("teamID", isMember)), ok)
+
+	phase := "/" + uuid.NewString()
+	student := kc.Token().Subject("student")
+	tests := []struct {
+		name       string
+		path       string
+		token      *keycloakTesting.TokenBuilder
+		wantStatus int
+	}{
+		{"own participation", "/participations/" + ownParticipationID.String(), student, http.StatusOK},
+		{"mismatched participation", "/participations/" + otherParticipationID.String(), student, http.StatusForbidden},
+		{"invalid participation ID", "/participations/not-a-uuid", student, http.StatusForbidden},
+		{"missing participation", "/participations/" + uuid.Nil.String(), kc.Token().Subject("applicant"), http.StatusForbidden},
+		{"not a student", "/participations/" + ownParticipationID.String(), kc.Token().Subject("someone"), http.StatusForbidden},
+		{"course lecturer", "/participations/" + otherParticipationID.String(), kc.Token().Subject("lecturer").Roles("ios25-Lecturer"), http.StatusOK},
+		{"course editor", "/participations/" + otherParticipationID.String(), kc.Token().Subject("editor").Roles("ios25-Editor"), http.StatusOK},
+		{"prompt admin", "/participations/" + otherParticipationID.String(), kc.Token().Subject("admin").Roles(PromptAdmin), http.StatusOK},
+		{"own team", "/teams/own-team", student, http.StatusOK},
+		{"other team", "/teams/other-team", student, http.StatusForbidden},
+		{"team of a non-student", "/teams/own-team", kc.Token().Subject("someone"), http.StatusForbidden},
+		{"course lecturer of other team", "/teams/other-team", kc.Token().Subject("lecturer").Roles("ios25-Lecturer"), http.StatusOK},
+		{"failing membership check", "/teams/broken", student, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rec := serve(router, phase+tt.path, tt.token.BearerHeader())
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d; want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
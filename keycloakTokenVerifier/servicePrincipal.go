@@ -0,0 +1,98 @@
+package keycloakTokenVerifier
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const servicePrincipalContextKey = "servicePrincipal"
+
+// ServicePrincipal is the caller of a request authenticated with a client-credentials token of another service,
+// set by ServiceMiddleware instead of a TokenUser.
+type ServicePrincipal struct {
+	// ClientID is the Keycloak client of the calling service (azp claim).
+	ClientID string
+	// ID is the ID of the client's service account user (sub claim).
+	ID          string
+	Roles       map[string]bool
+	RoleOrigins map[string][]string
+	ExpiresAt   time.Time
+}
+
+func GetServicePrincipal(c *gin.Context) (ServicePrincipal, bool) {
+	if principal, exists := c.Get(servicePrincipalContextKey); exists {
+		p, ok := principal.(ServicePrincipal)
+		return p, ok
+	}
+	return ServicePrincipal{}, false
+}
+
+func SetServicePrincipal(c *gin.Context, principal ServicePrincipal) {
+	c.Set(servicePrincipalContextKey, principal)
+}
+
+// ServiceMiddleware is Verifier.ServiceMiddleware of the default verifier.
+func ServiceMiddleware(allowedClients ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v := loadDefaultVerifier(c); v != nil {
+			v.serviceMiddleware(c, allowedClients)
+		}
+	}
+}
+
+// ServiceMiddleware accepts only client-credentials tokens of the service accounts of allowedClients, e.g. of other
+// modules calling from background jobs, and stores the caller as ServicePrincipal (see GetServicePrincipal).
+// Its roles are read from the configured role sources like the roles of a TokenUser.
+// User tokens and tokens of other clients are answered with 403.
+func (v *Verifier) ServiceMiddleware(allowedClients ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v.serviceMiddleware(c, allowedClients)
+	}
+}
+
+func (v *Verifier) serviceMiddleware(c *gin.Context, allowedClients []string) {
+	token, ok := v.verifyBearerToken(c)
+	if !ok {
+		return
+	}
+	claims := token.claims
+
+	clientID, _ := claims["azp"].(string)
+	if !isServiceAccountToken(claims, clientID) || !slices.Contains(allowedClients, clientID) {
+		v.logger.Error("Token is not a service account token of an allowed client: ", clientID)
+		abortWithProblem(c, http.StatusForbidden, ProblemForbidden, "service account token of an allowed client required")
+		return
+	}
+
+	roles, roleOrigins, err := v.checkKeycloakRoles(claims)
+	if err != nil {
+		abortUnauthenticated(c, ProblemInvalidToken, "could not authenticate service")
+		return
+	}
+
+	SetServicePrincipal(c, ServicePrincipal{
+		ClientID:    clientID,
+		ID:          token.subject,
+		Roles:       roles,
+		RoleOrigins: roleOrigins,
+		ExpiresAt:   token.expiresAt,
+	})
+}
+
+// isServiceAccountToken reports whether the token was issued to the service account of clientID
+// with the client-credentials grant: only then Keycloak adds the client_id (formerly clientId) claim.
+// The username "service-account-<clientID>" is not trusted, as users may be able to choose their username.
+func isServiceAccountToken(claims map[string]interface{}, clientID string) bool {
+	if clientID == "" {
+		return false
+	}
+	for _, key := range []string{"client_id", "clientId"} {
+		if id, ok := claims[key].(string); ok && id == clientID {
+			return true
+		}
+	}
+	return false
+}
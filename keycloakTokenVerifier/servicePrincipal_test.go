@@ -0,0 +1,81 @@
+package keycloakTokenVerifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+func TestVerifier_ServiceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+	kc.AddServiceAccount("assessment-module", "secret", "Sync")
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid")
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/sync", v.ServiceMiddleware("assessment-module"), func(c *gin.Context) {
+		principal, ok := GetServicePrincipal(c)
+		if _, isUser := GetTokenUser(c); !ok || isUser {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, principal)
+	})
+
+	source := utils.NewClientCredentialsTokenSource(utils.ClientCredentialsConfig{
+		TokenURL:     kc.TokenURL(),
+		ClientID:     "assessment-module",
+		ClientSecret: "secret",
+	})
+	serviceHeader, err := source.AuthHeader(context.Background())
+	if err != nil {
+		t.Fatalf("AuthHeader() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		authHeader string
+		wantStatus int
+	}{
+		{"service account token", serviceHeader, http.StatusOK},
+		{"user token", kc.Token().Roles(PromptAdmin).BearerHeader(), http.StatusForbidden},
+		{"user token of the service client", kc.Token().AuthorizedParty("assessment-module").BearerHeader(), http.StatusForbidden},
+		{"user named like the service account", kc.Token().AuthorizedParty("assessment-module").PreferredUsername("service-account-assessment-module").BearerHeader(), http.StatusForbidden},
+		{"other service account", kc.ServiceToken("other-module").BearerHeader(), http.StatusForbidden},
+		{"no token", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodPost, "/sync", nil)
+			req.Header.Set("Authorization", tt.authHeader)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d; want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var principal ServicePrincipal
+			if err := json.Unmarshal(rec.Body.Bytes(), &principal); err != nil {
+				t.Fatalf("decode principal: %v", err)
+			}
+			if principal.ClientID != "assessment-module" || principal.ID == "" || !principal.Roles["Sync"] {
+				t.Errorf("principal = %+v", principal)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package testing
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/google/uuid"
+)
+
+// Opaque registers the claims of the token under a random opaque (non-JWT) token, which
+// only the introspection endpoint can resolve, and returns it.
+func (b *TokenBuilder) Opaque() string {
+	token := uuid.NewString()
+	b.server.mu.Lock()
+	defer b.server.mu.Unlock()
+	b.server.opaqueTokens[token] = b.claims
+	return token
+}
+
+// Revoke marks a signed or opaque token as revoked, so that the introspection endpoint reports it as inactive,
+// like Keycloak does after a logout or a revoked session.
+func (s *Server) Revoke(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[token] = true
+}
+
+// IntrospectionCount returns the number of requests to the introspection endpoint.
+func (s *Server) IntrospectionCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.introspections
+}
+
+// handleIntrospect implements the token introspection endpoint (RFC 7662) for clients registered with AddServiceAccount.
+func (s *Server) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("realm") != s.Realm {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clientID, secret, _ := r.BasicAuth()
+	s.mu.Lock()
+	s.introspections++
+	account, exists := s.serviceAccounts[clientID]
+	s.mu.Unlock()
+	if !exists || account.secret != secret {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	claims, ok := s.tokenClaims(r.PostForm.Get("token"))
+	if !ok {
+		writeJSON(w, map[string]interface{}{"active": false})
+		return
+	}
+	response := map[string]interface{}{"active": true}
+	for key, value := range claims {
+		response[key] = value
+	}
+	writeJSON(w, response)
+}
+
+// tokenClaims returns the claims of an active token: a token signed with a published key or an opaque token,
+// which is neither expired nor revoked.
+func (s *Server) tokenClaims(token string) (map[string]interface{}, bool) {
+	s.mu.RLock()
+	revoked := s.revoked[token]
+	claims, opaque := s.opaqueTokens[token]
+	s.mu.RUnlock()
+	if revoked {
+		return nil, false
+	}
+
+	if !opaque {
+		signed, err := jose.ParseSigned(token, []jose.SignatureAlgorithm{jose.RS256})
+		if err != nil {
+			return nil, false
+		}
+		var payload []byte
+		for _, key := range s.publishedKeys() {
+			if payload, err = signed.Verify(key.Public()); err == nil {
+				break
+			}
+		}
+		if err != nil || json.Unmarshal(payload, &claims) != nil {
+			return nil, false
+		}
+	}
+
+	var exp float64
+	switch value := claims["exp"].(type) {
+	case float64:
+		exp = value
+	case int64:
+		exp = float64(value)
+	}
+	if time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, false
+	}
+	return claims, true
+}
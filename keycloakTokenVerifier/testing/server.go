@@ -0,0 +1,193 @@
+// Package testing provides an in-process Keycloak stub for tests of services using the keycloakTokenVerifier.
+//
+// The Server serves the OIDC discovery document and the JWKS of a single realm and signs tokens
+// built with Server.Token, so that the authentication middleware can be exercised end-to-end
+// without a running Keycloak:
+//
+//	kc := testing.NewServer("prompt")
+//	defer kc.Close()
+//	_ = keycloakTokenVerifier.InitKeycloakTokenVerifier(kc.URL(), kc.Realm, coreURL)
+//
+//	req.Header.Set("Authorization", kc.Token().Subject("user").Roles(keycloakTokenVerifier.PromptAdmin).BearerHeader())
+package testing
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultClientID is the client whose roles are put into resource_access by TokenBuilder.Roles.
+	DefaultClientID = "prompt-server"
+	// DefaultAuthorizedParty is the azp claim of built tokens.
+	DefaultAuthorizedParty = "prompt-client"
+)
+
+// Server is an in-process OIDC provider for one Keycloak realm.
+type Server struct {
+	Realm string
+	// ClientID is used by TokenBuilder.Roles, AuthorizedParty is the default azp of built tokens.
+	ClientID        string
+	AuthorizedParty string
+
+	server *httptest.Server
+
+	mu  sync.RWMutex
+	key jose.JSONWebKey
+	// previousKeys are still published in the JWKS after RotateKey.
+	previousKeys    []jose.JSONWebKey
+	unavailable     bool
+	serviceAccounts map[string]serviceAccount
+	opaqueTokens    map[string]map[string]interface{}
+	revoked         map[string]bool
+	introspections  int
+}
+
+// NewServer starts a Server for the given realm. It panics if the signing key cannot be generated,
+// like httptest.NewServer panics if it cannot listen. The caller must Close the server.
+func NewServer(realm string) *Server {
+	s := &Server{
+		Realm:           realm,
+		ClientID:        DefaultClientID,
+		AuthorizedParty: DefaultAuthorizedParty,
+		key:             newSigningKey(),
+		serviceAccounts: make(map[string]serviceAccount),
+		opaqueTokens:    make(map[string]map[string]interface{}),
+		revoked:         make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /realms/{realm}/.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("GET /realms/{realm}/protocol/openid-connect/certs", s.handleJWKS)
+	mux.HandleFunc("POST /realms/{realm}/protocol/openid-connect/token", s.handleToken)
+	mux.HandleFunc("POST /realms/{realm}/protocol/openid-connect/token/introspect", s.handleIntrospect)
+	s.server = httptest.NewServer(s.availability(mux))
+	return s
+}
+
+// URL returns the Keycloak base URL, as passed to InitKeycloakTokenVerifier.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Issuer returns the issuer of the realm, which is put into the iss claim of built tokens.
+func (s *Server) Issuer() string {
+	return s.server.URL + "/realms/" + s.Realm
+}
+
+// TokenURL returns the token endpoint of the realm, which issues client-credentials tokens of the registered service accounts.
+func (s *Server) TokenURL() string {
+	return s.Issuer() + "/protocol/openid-connect/token"
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// RotateKey signs the following tokens with a new key. The previous keys stay published in the JWKS,
+// like Keycloak keeps passive keys, unless dropPrevious is set.
+func (s *Server) RotateKey(dropPrevious bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if dropPrevious {
+		s.previousKeys = nil
+	} else {
+		s.previousKeys = append(s.previousKeys, s.key)
+	}
+	s.key = newSigningKey()
+}
+
+// KeyID returns the key ID of the current signing key.
+func (s *Server) KeyID() string {
+	return s.signingKey().KeyID
+}
+
+// SetUnavailable makes all endpoints respond with 503, e.g. to test start-up while Keycloak is down.
+func (s *Server) SetUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unavailable = unavailable
+}
+
+func (s *Server) availability(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		unavailable := s.unavailable
+		s.mu.RUnlock()
+		if unavailable {
+			http.Error(w, "keycloak unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// publishedKeys returns the current and the previous signing keys.
+func (s *Server) publishedKeys() []jose.JSONWebKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]jose.JSONWebKey{s.key}, s.previousKeys...)
+}
+
+func (s *Server) signingKey() jose.JSONWebKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.key
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("realm") != s.Realm {
+		http.NotFound(w, r)
+		return
+	}
+	issuer := s.Issuer()
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/protocol/openid-connect/auth",
+		"token_endpoint":                        issuer + "/protocol/openid-connect/token",
+		"jwks_uri":                              issuer + "/protocol/openid-connect/certs",
+		"introspection_endpoint":                issuer + "/protocol/openid-connect/token/introspect",
+		"id_token_signing_alg_values_supported": []string{string(jose.RS256)},
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("realm") != s.Realm {
+		http.NotFound(w, r)
+		return
+	}
+	var jwks jose.JSONWebKeySet
+	for _, key := range s.publishedKeys() {
+		jwks.Keys = append(jwks.Keys, key.Public())
+	}
+	writeJSON(w, jwks)
+}
+
+func newSigningKey() jose.JSONWebKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("keycloak testing: failed to generate signing key: %v", err))
+	}
+	return jose.JSONWebKey{
+		Key:       privateKey,
+		KeyID:     uuid.NewString(),
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
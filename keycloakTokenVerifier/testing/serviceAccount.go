@@ -0,0 +1,71 @@
+package testing
+
+import (
+	"net/http"
+	"time"
+)
+
+// serviceAccountTokenLifetime is the lifetime of the tokens issued by the token endpoint.
+const serviceAccountTokenLifetime = 5 * time.Minute
+
+type serviceAccount struct {
+	secret string
+	roles  []string
+}
+
+// AddServiceAccount registers a confidential client with the given secret, whose service account
+// has the given roles of the server's ClientID. The token endpoint (TokenURL) issues tokens for it
+// with the client-credentials grant.
+func (s *Server) AddServiceAccount(clientID, secret string, roles ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serviceAccounts[clientID] = serviceAccount{secret: secret, roles: roles}
+}
+
+// ServiceToken starts a token of the service account of clientID, as issued by Keycloak for the
+// client-credentials grant: azp and client_id are the client and preferred_username is "service-account-<clientID>".
+func (s *Server) ServiceToken(clientID string) *TokenBuilder {
+	return s.Token().
+		AuthorizedParty(clientID).
+		PreferredUsername("service-account-"+clientID).
+		Claim("client_id", clientID)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("realm") != s.Realm {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	s.mu.RLock()
+	account, exists := s.serviceAccounts[clientID]
+	s.mu.RUnlock()
+	if !exists || account.secret != secret {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	token, err := s.ServiceToken(clientID).
+		Roles(account.roles...).
+		ExpiresAt(time.Now().Add(serviceAccountTokenLifetime)).
+		Sign()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(serviceAccountTokenLifetime.Seconds()),
+	})
+}
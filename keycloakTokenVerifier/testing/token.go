@@ -0,0 +1,154 @@
+package testing
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/google/uuid"
+)
+
+// TokenBuilder builds access tokens signed by the Server. Create it with Server.Token.
+type TokenBuilder struct {
+	server *Server
+	claims map[string]interface{}
+}
+
+// Token starts a token of a random subject, issued now and valid for one hour.
+func (s *Server) Token() *TokenBuilder {
+	now := time.Now()
+	return &TokenBuilder{
+		server: s,
+		claims: map[string]interface{}{
+			"iss": s.Issuer(),
+			"sub": uuid.NewString(),
+			"azp": s.AuthorizedParty,
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		},
+	}
+}
+
+func (b *TokenBuilder) Subject(sub string) *TokenBuilder {
+	return b.Claim("sub", sub)
+}
+
+func (b *TokenBuilder) Email(email string) *TokenBuilder {
+	return b.Claim("email", email)
+}
+
+func (b *TokenBuilder) Name(firstName, lastName string) *TokenBuilder {
+	return b.Claim("given_name", firstName).Claim("family_name", lastName)
+}
+
+func (b *TokenBuilder) MatriculationNumber(matriculationNumber string) *TokenBuilder {
+	return b.Claim("matriculation_number", matriculationNumber)
+}
+
+func (b *TokenBuilder) UniversityLogin(universityLogin string) *TokenBuilder {
+	return b.Claim("university_login", universityLogin)
+}
+
+func (b *TokenBuilder) AuthorizedParty(azp string) *TokenBuilder {
+	return b.Claim("azp", azp)
+}
+
+// Audience replaces the aud claim.
+func (b *TokenBuilder) Audience(aud ...string) *TokenBuilder {
+	return b.Claim("aud", aud)
+}
+
+// Roles adds roles of the server's ClientID, see ClientRoles.
+func (b *TokenBuilder) Roles(roles ...string) *TokenBuilder {
+	return b.ClientRoles(b.server.ClientID, roles...)
+}
+
+// ClientRoles adds roles to resource_access[clientID].roles and the client to the audience,
+// as Keycloak does for client roles.
+func (b *TokenBuilder) ClientRoles(clientID string, roles ...string) *TokenBuilder {
+	resourceAccess, _ := b.claims["resource_access"].(map[string]interface{})
+	if resourceAccess == nil {
+		resourceAccess = make(map[string]interface{})
+		b.claims["resource_access"] = resourceAccess
+	}
+	client, _ := resourceAccess[clientID].(map[string]interface{})
+	if client == nil {
+		client = map[string]interface{}{"roles": []string{}}
+		resourceAccess[clientID] = client
+	}
+	client["roles"] = append(client["roles"].([]string), roles...)
+
+	aud, _ := b.claims["aud"].([]string)
+	for _, existing := range aud {
+		if existing == clientID {
+			return b
+		}
+	}
+	return b.Claim("aud", append(aud, clientID))
+}
+
+// RealmRoles adds roles to realm_access.roles.
+func (b *TokenBuilder) RealmRoles(roles ...string) *TokenBuilder {
+	realmAccess, _ := b.claims["realm_access"].(map[string]interface{})
+	if realmAccess == nil {
+		realmAccess = map[string]interface{}{"roles": []string{}}
+		b.claims["realm_access"] = realmAccess
+	}
+	realmAccess["roles"] = append(realmAccess["roles"].([]string), roles...)
+	return b
+}
+
+func (b *TokenBuilder) SessionID(sid string) *TokenBuilder {
+	return b.Claim("sid", sid)
+}
+
+func (b *TokenBuilder) PreferredUsername(username string) *TokenBuilder {
+	return b.Claim("preferred_username", username)
+}
+
+// ExpiresAt sets the exp claim.
+func (b *TokenBuilder) ExpiresAt(exp time.Time) *TokenBuilder {
+	return b.Claim("exp", exp.Unix())
+}
+
+// Claim sets an arbitrary claim.
+func (b *TokenBuilder) Claim(key string, value interface{}) *TokenBuilder {
+	b.claims[key] = value
+	return b
+}
+
+// Sign serializes and signs the token with the server's current key.
+func (b *TokenBuilder) Sign() (string, error) {
+	payload, err := json.Marshal(b.claims)
+	if err != nil {
+		return "", err
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: b.server.signingKey()},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return signed.CompactSerialize()
+}
+
+// MustSign is Sign, but panics on error.
+func (b *TokenBuilder) MustSign() string {
+	token, err := b.Sign()
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// BearerHeader returns the signed token as value of an Authorization header.
+func (b *TokenBuilder) BearerHeader() string {
+	return "Bearer " + b.MustSign()
+}
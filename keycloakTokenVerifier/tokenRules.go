@@ -0,0 +1,130 @@
+package keycloakTokenVerifier
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	audiencesContextKey         = "allowedAudiences"
+	authorizedPartiesContextKey = "allowedAuthorizedParties"
+)
+
+// TokenClaimError reports a token whose aud or azp claim is not allowed for the route.
+type TokenClaimError struct {
+	// Claim is "aud" or "azp".
+	Claim string
+	// Values are the values of the claim in the token.
+	Values []string
+	// Allowed are the values accepted by the route.
+	Allowed []string
+}
+
+func (e *TokenClaimError) Error() string {
+	return fmt.Sprintf("token %s %v not in allowed values %v", e.Claim, e.Values, e.Allowed)
+}
+
+// UseAudiences returns a handler that makes the middlewares of the following handlers require one of the
+// audiences in the aud claim of the token, instead of the ones set with WithAudiences (default none), e.g.
+//
+//	admin := router.Group("/admin", UseAudiences("prompt-server"), UseAuthorizedParties("prompt-client"))
+//
+// UseAudiences() without audiences explicitly opts the following handlers out of the audience check,
+// including the audiences set with WithAudiences.
+func UseAudiences(audiences ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(audiencesContextKey, audiences)
+	}
+}
+
+// UseAuthorizedParties returns a handler that makes the middlewares of the following handlers accept the
+// given values of the azp claim instead of the ones set with WithAuthorizedParties (default "prompt-client"),
+// e.g. to also accept the clients of a public application form.
+func UseAuthorizedParties(authorizedParties ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(authorizedPartiesContextKey, authorizedParties)
+	}
+}
+
+// allowedAudiences returns the audiences required for the request; none means the audience is not checked.
+func (v *Verifier) allowedAudiences(c *gin.Context) []string {
+	if audiences, ok := c.Get(audiencesContextKey); ok {
+		if a, ok := audiences.([]string); ok {
+			return a
+		}
+	}
+	return v.config.audiences
+}
+
+// allowedAuthorizedParties returns the azp values accepted for the request.
+func (v *Verifier) allowedAuthorizedParties(c *gin.Context) []string {
+	if parties, ok := c.Get(authorizedPartiesContextKey); ok {
+		if p, ok := parties.([]string); ok {
+			return p
+		}
+	}
+	return v.config.authorizedParties
+}
+
+// checkAudiences returns a *TokenClaimError unless the token has one of the allowed audiences.
+func checkAudiences(claims map[string]interface{}, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, audience := range allowed {
+		if checkAudience(claims, audience) {
+			return nil
+		}
+	}
+	return &TokenClaimError{Claim: "aud", Values: audiences(claims), Allowed: allowed}
+}
+
+// checkAuthorizedParties returns a *TokenClaimError unless the azp claim is one of the allowed parties.
+func checkAuthorizedParties(claims map[string]interface{}, allowed []string) error {
+	if checkAuthorizedParty(claims, allowed) {
+		return nil
+	}
+	var values []string
+	if azp, ok := claims["azp"].(string); ok {
+		values = []string{azp}
+	}
+	return &TokenClaimError{Claim: "azp", Values: values, Allowed: allowed}
+}
+
+// abortWithClaimError logs the rejected claim and aborts the request with 401. The values and the allowed
+// values are only logged and attached to the context, the response does not reveal them.
+func (v *Verifier) abortWithClaimError(c *gin.Context, err *TokenClaimError) {
+	v.logger.WithFields(log.Fields{
+		"claim":   err.Claim,
+		"values":  err.Values,
+		"allowed": err.Allowed,
+		"route":   c.FullPath(),
+	}).Warn("Token rejected: ", err)
+	_ = c.Error(err)
+
+	code := ProblemInvalidAudience
+	if err.Claim == "azp" {
+		code = ProblemInvalidAuthorizedParty
+	}
+	abortWithProblem(c, http.StatusUnauthorized, code, "token not accepted for this route")
+}
+
+func audiences(claims map[string]interface{}) []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		values := make([]string, 0, len(aud))
+		for _, item := range aud {
+			if str, ok := item.(string); ok && !slices.Contains(values, str) {
+				values = append(values, str)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package keycloakTokenVerifier
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
+)
+
+func TestCheckAudiences(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		allowed []string
+		wantErr bool
+	}{
+		{"no allowed audiences", map[string]interface{}{}, nil, false},
+		{"single audience", map[string]interface{}{"aud": "prompt-server"}, []string{"prompt-server"}, false},
+		{"one of multiple audiences", map[string]interface{}{"aud": []interface{}{"account", "prompt-server"}}, []string{"prompt-server", "ios-server"}, false},
+		{"other audience", map[string]interface{}{"aud": []interface{}{"account"}}, []string{"prompt-server"}, true},
+		{"missing audience", map[string]interface{}{}, []string{"prompt-server"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := checkAudiences(tt.claims, tt.allowed)
+			var claimErr *TokenClaimError
+			if tt.wantErr != errors.As(err, &claimErr) {
+				t.Fatalf("checkAudiences() error = %v; want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && (claimErr.Claim != "aud" || !slices.Equal(claimErr.Allowed, tt.allowed)) {
+				t.Errorf("error = %+v", claimErr)
+			}
+		})
+	}
+}
+
+func TestVerifier_RouteTokenRules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid")
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.GET("/default", v.KeycloakMiddleware(), ok)
+	admin := router.Group("/admin", UseAudiences(kc.ClientID))
+	admin.GET("/settings", v.KeycloakMiddleware(), ok)
+	apply := router.Group("/apply", UseAuthorizedParties(kc.AuthorizedParty, "prompt-apply"))
+	apply.GET("/form", v.KeycloakMiddleware(), ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		authHeader string
+		wantStatus int
+		wantCode   string
+	}{
+		{"default without audience", "/default", kc.Token().BearerHeader(), http.StatusOK, ""},
+		{"strict with audience", "/admin/settings", kc.Token().Roles(PromptAdmin).BearerHeader(), http.StatusOK, ""},
+		{"strict without audience", "/admin/settings", kc.Token().BearerHeader(), http.StatusUnauthorized, ProblemInvalidAudience},
+		{"strict with other audience", "/admin/settings", kc.Token().Audience("account").BearerHeader(), http.StatusUnauthorized, ProblemInvalidAudience},
+		{"lenient with extra party", "/apply/form", kc.Token().AuthorizedParty("prompt-apply").BearerHeader(), http.StatusOK, ""},
+		{"default with extra party", "/default", kc.Token().AuthorizedParty("prompt-apply").BearerHeader(), http.StatusUnauthorized, ProblemInvalidAuthorizedParty},
+		{"lenient with unknown party", "/apply/form", kc.Token().AuthorizedParty("other").BearerHeader(), http.StatusUnauthorized, ProblemInvalidAuthorizedParty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rec := serve(router, tt.path, tt.authHeader)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d; want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if problem.Code != tt.wantCode {
+				t.Errorf("code = %q; want %q", problem.Code, tt.wantCode)
+			}
+			for _, allowed := range []string{kc.ClientID, kc.AuthorizedParty, "prompt-apply"} {
+				if strings.Contains(problem.Message, allowed) {
+					t.Errorf("message %q reveals the allowed value %q", problem.Message, allowed)
+				}
+			}
+		})
+	}
+}
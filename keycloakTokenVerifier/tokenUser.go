@@ -1,7 +1,11 @@
 package keycloakTokenVerifier
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"maps"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -9,12 +13,17 @@ import (
 
 const tokenUserContextKey = "tokenUser"
 
+// tokenUserKey is the context.Context key of the TokenUser.
+type tokenUserKey struct{}
+
 var ErrUserNotInContext = errors.New("user not found in context")
 
 // TokenUser encapsulates a user's authentication information, including roles,
 // personal identifiers, and permissions within the system.
 type TokenUser struct {
-	Roles               map[string]bool
+	Roles map[string]bool
+	// RoleOrigins lists for each role the role sources it was read from, e.g. ["realm", "client:prompt-server"].
+	RoleOrigins         map[string][]string
 	ID                  string
 	Email               string
 	MatriculationNumber string
@@ -29,6 +38,46 @@ type TokenUser struct {
 	IsLecturer       bool
 	IsEditor         bool
 	CustomRolePrefix string
+
+	// ExpiresAt and IssuedAt are the exp and iat claims of the token.
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+	// SessionID is the Keycloak session of the token (sid claim).
+	SessionID         string
+	PreferredUsername string
+	// RealmRoles are the roles of realm_access.roles.
+	RealmRoles []string
+
+	// claims is the verified claim set of the token, read with Claim and Claims.
+	claims map[string]interface{}
+}
+
+// Claims returns a copy of the verified claims of the token. Nested claim values are shared.
+func (t TokenUser) Claims() map[string]interface{} {
+	return maps.Clone(t.claims)
+}
+
+// Claim returns the claim key of the token user converted to T, e.g. Claim[string](tokenUser, "locale")
+// or Claim[[]string](tokenUser, "groups"). JSON numbers can be read as any numeric type and objects as structs.
+// ok is false if the claim is missing or cannot be converted to T.
+func Claim[T any](tokenUser TokenUser, key string) (value T, ok bool) {
+	raw, exists := tokenUser.claims[key]
+	if !exists {
+		return value, false
+	}
+	if value, ok = raw.(T); ok {
+		return value, true
+	}
+
+	// convert e.g. float64 to int or []interface{} to []string the way encoding/json would
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return value, false
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, false
+	}
+	return value, true
 }
 
 func GetTokenUser(c *gin.Context) (TokenUser, bool) {
@@ -42,6 +91,47 @@ func GetTokenUser(c *gin.Context) (TokenUser, bool) {
 	return TokenUser{}, false
 }
 
+// MustTokenUser returns the TokenUser set by the middlewares. It panics with ErrUserNotInContext
+// if the route is not protected by one of them.
+func MustTokenUser(c *gin.Context) TokenUser {
+	tokenUser, ok := GetTokenUser(c)
+	if !ok {
+		panic(ErrUserNotInContext)
+	}
+	return tokenUser
+}
+
+// UserID returns the ID of the authenticated user, or "" if there is none.
+func UserID(c *gin.Context) string {
+	tokenUser, _ := GetTokenUser(c)
+	return tokenUser.ID
+}
+
+// CourseParticipationID returns the course participation of the user in the course phase,
+// or uuid.Nil if the user is no student or the student role was not resolved for the request.
+func CourseParticipationID(c *gin.Context) uuid.UUID {
+	tokenUser, _ := GetTokenUser(c)
+	return tokenUser.CourseParticipationID
+}
+
+// SetTokenUser stores the TokenUser in the gin context and in the context of the request,
+// so that code below the handlers can read it with TokenUserFromContext(c.Request.Context()).
 func SetTokenUser(c *gin.Context, tokenUser TokenUser) {
 	c.Set(tokenUserContextKey, tokenUser)
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(WithTokenUser(c.Request.Context(), tokenUser))
+	}
+}
+
+// WithTokenUser returns a copy of ctx carrying the TokenUser, e.g. for service and repository layers,
+// SQL audit hooks or outgoing requests that take a plain context.Context.
+func WithTokenUser(ctx context.Context, tokenUser TokenUser) context.Context {
+	return context.WithValue(ctx, tokenUserKey{}, tokenUser)
+}
+
+// TokenUserFromContext returns the TokenUser stored with WithTokenUser, e.g. by the middlewares
+// in the request context.
+func TokenUserFromContext(ctx context.Context) (TokenUser, bool) {
+	tokenUser, ok := ctx.Value(tokenUserKey{}).(TokenUser)
+	return tokenUser, ok
 }
@@ -1,12 +1,15 @@
 package keycloakTokenVerifier
 
 import (
+	"context"
+	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	keycloakTesting "github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/testing"
 )
 
 func TestGetTokenUser_NoTokenSet(t *testing.T) {
@@ -51,3 +54,131 @@ func TestSetAndGetTokenUser(t *testing.T) {
 		t.Errorf("mismatch:\n got %#v\nwant %#v", got, expected)
 	}
 }
+
+func TestClaim(t *testing.T) {
+	tokenUser := TokenUser{claims: map[string]interface{}{
+		"locale":   "de",
+		"level":    float64(3),
+		"groups":   []interface{}{"a", "b"},
+		"features": map[string]interface{}{"beta": true},
+	}}
+
+	if got, ok := Claim[string](tokenUser, "locale"); !ok || got != "de" {
+		t.Errorf("Claim[string] = %q, %v", got, ok)
+	}
+	if got, ok := Claim[int](tokenUser, "level"); !ok || got != 3 {
+		t.Errorf("Claim[int] = %d, %v", got, ok)
+	}
+	if got, ok := Claim[[]string](tokenUser, "groups"); !ok || !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Claim[[]string] = %v, %v", got, ok)
+	}
+	if got, ok := Claim[struct{ Beta bool }](tokenUser, "features"); !ok || !got.Beta {
+		t.Errorf("Claim[struct] = %v, %v", got, ok)
+	}
+	if _, ok := Claim[int](tokenUser, "locale"); ok {
+		t.Errorf("expected a string claim not to convert to int")
+	}
+	if _, ok := Claim[string](tokenUser, "missing"); ok {
+		t.Errorf("expected a missing claim to return ok=false")
+	}
+}
+
+func TestClaims_ReturnsCopy(t *testing.T) {
+	tokenUser := TokenUser{claims: map[string]interface{}{"locale": "de"}}
+
+	claims := tokenUser.Claims()
+	claims["locale"] = "en"
+	claims["email"] = "attacker@example.com"
+
+	if got, ok := Claim[string](tokenUser, "locale"); !ok || got != "de" {
+		t.Errorf("Claim[string] after modifying Claims() = %q, %v; want \"de\", true", got, ok)
+	}
+	if _, ok := Claim[string](tokenUser, "email"); ok {
+		t.Errorf("expected a claim added to the copy not to be visible")
+	}
+}
+
+func TestTypedAccessors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if UserID(c) != "" || CourseParticipationID(c) != uuid.Nil {
+		t.Errorf("expected zero values without token user")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != ErrUserNotInContext {
+				t.Errorf("MustTokenUser() panic = %v; want %v", r, ErrUserNotInContext)
+			}
+		}()
+		MustTokenUser(c)
+	}()
+
+	participationID := uuid.New()
+	SetTokenUser(c, TokenUser{ID: "user-1", CourseParticipationID: participationID})
+	if got := UserID(c); got != "user-1" {
+		t.Errorf("UserID() = %q; want %q", got, "user-1")
+	}
+	if got := CourseParticipationID(c); got != participationID {
+		t.Errorf("CourseParticipationID() = %v; want %v", got, participationID)
+	}
+	if got := MustTokenUser(c); got.ID != "user-1" {
+		t.Errorf("MustTokenUser().ID = %q; want %q", got.ID, "user-1")
+	}
+}
+
+func TestTokenUserFromContext(t *testing.T) {
+	if _, ok := TokenUserFromContext(context.Background()); ok {
+		t.Errorf("expected no token user in empty context")
+	}
+
+	ctx := WithTokenUser(context.Background(), TokenUser{ID: "user-1"})
+	if got, ok := TokenUserFromContext(ctx); !ok || got.ID != "user-1" {
+		t.Errorf("TokenUserFromContext() = %+v, %v; want user-1, true", got, ok)
+	}
+}
+
+func TestSetTokenUser_RequestContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	SetTokenUser(c, TokenUser{ID: "user-1"})
+	SetTokenUser(c, TokenUser{ID: "user-1", IsLecturer: true})
+
+	got, ok := TokenUserFromContext(c.Request.Context())
+	if !ok || got.ID != "user-1" || !got.IsLecturer {
+		t.Errorf("TokenUserFromContext(c.Request.Context()) = %+v, %v; want the last token user", got, ok)
+	}
+}
+
+func TestAuthenticationMiddleware_RequestContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kc := keycloakTesting.NewServer("prompt")
+	t.Cleanup(kc.Close)
+
+	v, err := NewVerifier(kc.URL(), kc.Realm, "http://core.invalid")
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	t.Cleanup(v.Close)
+
+	// userIDOf stands for a service layer that only gets a context.Context
+	userIDOf := func(ctx context.Context) string {
+		tokenUser, _ := TokenUserFromContext(ctx)
+		return tokenUser.ID
+	}
+
+	router := gin.New()
+	router.GET("/me", v.AuthenticationMiddleware(PromptAdmin), func(c *gin.Context) {
+		c.String(http.StatusOK, userIDOf(c.Request.Context()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", kc.Token().Subject("user-1").Roles(PromptAdmin).BearerHeader())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "user-1" {
+		t.Errorf("got %d %q; want %d %q", rec.Code, rec.Body.String(), http.StatusOK, "user-1")
+	}
+}
@@ -0,0 +1,123 @@
+package keycloakTokenVerifier
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier/keycloakCoreRequests"
+	log "github.com/sirupsen/logrus"
+)
+
+// Verifier verifies the tokens of one Keycloak realm and resolves course phase roles from one Prompt Core.
+// Multiple verifiers can be used side by side, e.g. for different realms.
+type Verifier struct {
+	config       *KeycloakTokenVerifier
+	provider     *oidcProvider
+	core         *keycloakCoreRequests.Client
+	logger       log.FieldLogger
+	introspector *introspector
+
+	cacheMu sync.RWMutex
+	cache   CoursePhaseCache
+}
+
+// ErrNotInitialized is returned by the package-level functions if InitKeycloakTokenVerifier was not called.
+var ErrNotInitialized = errors.New("authentication not initialized")
+
+// defaultVerifier is used by the package-level middlewares, set by InitKeycloakTokenVerifier.
+var defaultVerifier atomic.Pointer[Verifier]
+
+// NewVerifier creates a Verifier and discovers the OIDC provider of the realm.
+func NewVerifier(KeycloakURL, Realm, CoreURL string, opts ...Option) (*Verifier, error) {
+	config, err := newConfig(KeycloakURL, Realm, CoreURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := newOIDCProvider(config)
+	if err != nil {
+		config.logger.Error("Failed to initialize keycloak verifier: ", err)
+		return nil, err
+	}
+
+	return &Verifier{
+		config:       config,
+		provider:     provider,
+		core:         keycloakCoreRequests.NewClient(config.CoreURL, config.coreHTTPClient(), config.logger),
+		logger:       config.logger,
+		introspector: newIntrospector(config),
+		cache:        config.coursePhaseCache,
+	}, nil
+}
+
+// DefaultVerifier returns the verifier initialized by InitKeycloakTokenVerifier, or nil.
+func DefaultVerifier() *Verifier {
+	return defaultVerifier.Load()
+}
+
+// Config returns a copy of the verifier's configuration.
+func (v *Verifier) Config() KeycloakTokenVerifier {
+	return *v.config
+}
+
+// Healthy reports whether the OIDC provider is discovered and signing keys are loaded, e.g. for a readiness probe.
+// A failed key refresh keeps the verifier healthy as long as the previous keys are available.
+func (v *Verifier) Healthy() bool {
+	return v.provider.status().Ready
+}
+
+// Status returns the state of the discovery and the signing keys, including the key IDs of the verified tokens.
+func (v *Verifier) Status() VerifierStatus {
+	return v.provider.status()
+}
+
+// Close stops the background discovery retries and key refreshes of the verifier.
+func (v *Verifier) Close() {
+	v.provider.close()
+}
+
+// Healthy is Verifier.Healthy of the default verifier; false if it is not initialized.
+func Healthy() bool {
+	v := defaultVerifier.Load()
+	return v != nil && v.Healthy()
+}
+
+// Status is Verifier.Status of the default verifier; the zero VerifierStatus if it is not initialized.
+func Status() VerifierStatus {
+	if v := defaultVerifier.Load(); v != nil {
+		return v.Status()
+	}
+	return VerifierStatus{}
+}
+
+// KeycloakMiddleware validates the token with the default verifier and extracts the claims from the token.
+func KeycloakMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v := loadDefaultVerifier(c); v != nil {
+			v.keycloakMiddleware(c)
+		}
+	}
+}
+
+// AuthenticationMiddleware is Verifier.AuthenticationMiddleware of the default verifier.
+func AuthenticationMiddleware(allowedRoles ...string) gin.HandlerFunc {
+	allowedSet := buildAllowedRolesSet(allowedRoles)
+	return func(c *gin.Context) {
+		if v := loadDefaultVerifier(c); v != nil {
+			v.authenticate(c, allowedRoles, allowedSet)
+		}
+	}
+}
+
+// loadDefaultVerifier returns the default verifier or aborts the request if it is not initialized.
+func loadDefaultVerifier(c *gin.Context) *Verifier {
+	v := defaultVerifier.Load()
+	if v == nil {
+		log.Error("Keycloak token verifier not initialized")
+		abortWithProblem(c, http.StatusInternalServerError, ProblemInternal, ErrNotInitialized.Error())
+	}
+	return v
+}
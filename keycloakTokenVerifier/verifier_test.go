@@ -0,0 +1,30 @@
+package keycloakTokenVerifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthenticationMiddleware_NotInitialized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if DefaultVerifier() != nil {
+		t.Skip("default verifier already initialized")
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", "Bearer token")
+
+	AuthenticationMiddleware(PromptAdmin)(c)
+
+	if !c.IsAborted() {
+		t.Fatalf("expected request to be aborted")
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
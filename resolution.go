@@ -1,6 +1,7 @@
 package promptSDK
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -67,8 +68,13 @@ func parseAndValidate(data []byte, dtoName string) (interface{}, error) {
 
 // ResolveParticipation resolves data for a single course participation.
 func ResolveParticipation(authHeader string, resolution Resolution, courseParticipationID uuid.UUID) (interface{}, error) {
+	return ResolveParticipationCtx(context.Background(), authHeader, resolution, courseParticipationID)
+}
+
+// ResolveParticipationCtx is ResolveParticipation bound to ctx.
+func ResolveParticipationCtx(ctx context.Context, authHeader string, resolution Resolution, courseParticipationID uuid.UUID) (interface{}, error) {
 	url := buildURL(resolution, courseParticipationID.String())
-	data, err := FetchJSON(url, authHeader)
+	data, err := FetchJSONCtx(ctx, url, authHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -78,8 +84,13 @@ func ResolveParticipation(authHeader string, resolution Resolution, coursePartic
 
 // ResolveCoursePhaseData resolves data for a course phase.
 func ResolveCoursePhaseData(authHeader string, resolution Resolution) (interface{}, error) {
+	return ResolveCoursePhaseDataCtx(context.Background(), authHeader, resolution)
+}
+
+// ResolveCoursePhaseDataCtx is ResolveCoursePhaseData bound to ctx.
+func ResolveCoursePhaseDataCtx(ctx context.Context, authHeader string, resolution Resolution) (interface{}, error) {
 	url := buildURL(resolution)
-	data, err := FetchJSON(url, authHeader)
+	data, err := FetchJSONCtx(ctx, url, authHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -89,8 +100,13 @@ func ResolveCoursePhaseData(authHeader string, resolution Resolution) (interface
 
 // ResolveAllParticipations resolves data for all participations and returns a map keyed by courseParticipationID.
 func ResolveAllParticipations(authHeader string, resolution Resolution) (map[uuid.UUID]interface{}, error) {
+	return ResolveAllParticipationsCtx(context.Background(), authHeader, resolution)
+}
+
+// ResolveAllParticipationsCtx is ResolveAllParticipations bound to ctx.
+func ResolveAllParticipationsCtx(ctx context.Context, authHeader string, resolution Resolution) (map[uuid.UUID]interface{}, error) {
 	url := buildURL(resolution)
-	data, err := FetchJSON(url, authHeader)
+	data, err := FetchJSONCtx(ctx, url, authHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -119,12 +135,30 @@ func ResolveAllParticipations(authHeader string, resolution Resolution) (map[uui
 }
 
 // FetchAndMergeParticipationsWithResolutions fetches participations and enriches each with resolved data.
-func FetchAndMergeParticipationsWithResolutions(coreURL string, authHeader string, coursePhaseID uuid.UUID) ([]promptTypes.CoursePhaseParticipationWithStudent, error) {
+// The DTO resolved for a participation is stored in its prevData under the DtoName of the resolution:
+//
+//	[{"courseParticipationID": "<id>", ..., "prevData": {"<dtoName>": <DTO of the participation>}}]
+//
+// Participations without an entry in the resolved list are left unchanged.
+// The resolutions are requested in parallel (see WithMaxConcurrency) and merged in their original order.
+// With WithPartialResults, failed resolutions are skipped and reported by a *PartialResolutionError.
+func FetchAndMergeParticipationsWithResolutions(coreURL string, authHeader string, coursePhaseID uuid.UUID, opts ...ResolutionOption) ([]promptTypes.CoursePhaseParticipationWithStudent, error) {
+	return FetchAndMergeParticipationsWithResolutionsCtx(context.Background(), coreURL, authHeader, coursePhaseID, opts...)
+}
+
+// FetchAndMergeParticipationsWithResolutionsCtx is FetchAndMergeParticipationsWithResolutions bound to ctx,
+// e.g. the context of the incoming request. Cancelling ctx cancels all upstream requests.
+func FetchAndMergeParticipationsWithResolutionsCtx(ctx context.Context, coreURL string, authHeader string, coursePhaseID uuid.UUID, opts ...ResolutionOption) ([]promptTypes.CoursePhaseParticipationWithStudent, error) {
+	config := newResolutionConfig(opts)
+	authHeader, err := config.authHeader(ctx, authHeader)
+	if err != nil {
+		return nil, err
+	}
 	url, err := url.JoinPath(coreURL, "api/course_phases", coursePhaseID.String(), "participations")
 	if err != nil {
 		return nil, err
 	}
-	data, err := FetchJSON(url, authHeader)
+	data, err := FetchJSONCtx(ctx, url, authHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -134,12 +168,19 @@ func FetchAndMergeParticipationsWithResolutions(coreURL string, authHeader strin
 		return nil, err
 	}
 
-	for _, res := range cppWithRes.Resolutions {
-		resolvedData, err := ResolveAllParticipations(authHeader, res)
-		if err != nil {
-			return nil, err
-		}
+	allResolvedData, resErrs := resolveConcurrently(ctx, cppWithRes.Resolutions, config.maxConcurrency, !config.partialResults, func(ctx context.Context, res Resolution) (map[uuid.UUID]interface{}, error) {
+		return ResolveAllParticipationsCtx(ctx, authHeader, res)
+	})
+	resolutionErr := config.resolutionError(resErrs)
+	if resolutionErr != nil && !config.partialResults {
+		return nil, resolutionErr
+	}
 
+	for resIdx, res := range cppWithRes.Resolutions {
+		if resErrs[resIdx] != nil {
+			continue
+		}
+		resolvedData := allResolvedData[resIdx]
 		for idx, participation := range cppWithRes.Participations {
 			if data, exists := resolvedData[participation.CourseParticipationID]; exists {
 				if participation.PrevData == nil {
@@ -151,16 +192,33 @@ func FetchAndMergeParticipationsWithResolutions(coreURL string, authHeader strin
 		}
 	}
 
-	return cppWithRes.Participations, nil
+	return cppWithRes.Participations, resolutionErr
 }
 
 // FetchAndMergeCourseParticipationWithResolution fetches a course participation by its courseParticipationID and enriches it with resolved data.
-func FetchAndMergeCourseParticipationWithResolution(coreURL string, authHeader string, coursePhaseID, courseParticipationID uuid.UUID) (promptTypes.CoursePhaseParticipationWithStudent, error) {
+// The resolved DTO is stored in prevData under the DtoName of the resolution:
+//
+//	{"courseParticipationID": "<id>", ..., "prevData": {"<dtoName>": <DTO>}}
+//
+// The resolutions are requested in parallel (see WithMaxConcurrency) and merged in their original order.
+// With WithPartialResults, failed resolutions are skipped and reported by a *PartialResolutionError.
+func FetchAndMergeCourseParticipationWithResolution(coreURL string, authHeader string, coursePhaseID, courseParticipationID uuid.UUID, opts ...ResolutionOption) (promptTypes.CoursePhaseParticipationWithStudent, error) {
+	return FetchAndMergeCourseParticipationWithResolutionCtx(context.Background(), coreURL, authHeader, coursePhaseID, courseParticipationID, opts...)
+}
+
+// FetchAndMergeCourseParticipationWithResolutionCtx is FetchAndMergeCourseParticipationWithResolution bound to ctx,
+// e.g. the context of the incoming request. Cancelling ctx cancels all upstream requests.
+func FetchAndMergeCourseParticipationWithResolutionCtx(ctx context.Context, coreURL string, authHeader string, coursePhaseID, courseParticipationID uuid.UUID, opts ...ResolutionOption) (promptTypes.CoursePhaseParticipationWithStudent, error) {
+	config := newResolutionConfig(opts)
+	authHeader, err := config.authHeader(ctx, authHeader)
+	if err != nil {
+		return promptTypes.CoursePhaseParticipationWithStudent{}, err
+	}
 	url, err := url.JoinPath(coreURL, "api/course_phases", coursePhaseID.String(), "participations", courseParticipationID.String())
 	if err != nil {
 		return promptTypes.CoursePhaseParticipationWithStudent{}, err
 	}
-	data, err := FetchJSON(url, authHeader)
+	data, err := FetchJSONCtx(ctx, url, authHeader)
 	if err != nil {
 		return promptTypes.CoursePhaseParticipationWithStudent{}, err
 	}
@@ -170,31 +228,56 @@ func FetchAndMergeCourseParticipationWithResolution(coreURL string, authHeader s
 		return promptTypes.CoursePhaseParticipationWithStudent{}, err
 	}
 
-	for _, res := range cppWithRes.Resolutions {
-		resolvedData, err := ResolveParticipation(authHeader, res, courseParticipationID)
-		if err != nil {
-			return promptTypes.CoursePhaseParticipationWithStudent{}, err
-		}
+	allResolvedData, resErrs := resolveConcurrently(ctx, cppWithRes.Resolutions, config.maxConcurrency, !config.partialResults, func(ctx context.Context, res Resolution) (interface{}, error) {
+		return ResolveParticipationCtx(ctx, authHeader, res, courseParticipationID)
+	}, courseParticipationID.String())
+	resolutionErr := config.resolutionError(resErrs)
+	if resolutionErr != nil && !config.partialResults {
+		return promptTypes.CoursePhaseParticipationWithStudent{}, resolutionErr
+	}
 
+	for resIdx, res := range cppWithRes.Resolutions {
+		if resErrs[resIdx] != nil {
+			continue
+		}
+		resolvedData := allResolvedData[resIdx]
 		participation := cppWithRes.Participation
 		if resolvedData != nil {
 			if participation.PrevData == nil {
 				participation.PrevData = make(promptTypes.MetaData)
 			}
-			participation.PrevData[res.DtoName] = data
+			participation.PrevData[res.DtoName] = resolvedData
 			cppWithRes.Participation = participation
 		}
 	}
 
-	return cppWithRes.Participation, nil
+	return cppWithRes.Participation, resolutionErr
 }
 
-func FetchAndMergeCoursePhaseWithResolution(coreURL string, authHeader string, coursePhaseID uuid.UUID) (promptTypes.MetaData, error) {
+// FetchAndMergeCoursePhaseWithResolution fetches the course phase data and enriches it with resolved data.
+// The resolved DTOs are added to the prevData of the course phase under the DtoName of each resolution:
+//
+//	{"<prevData key>": ..., "<dtoName>": <DTO>}
+//
+// The resolutions are requested in parallel (see WithMaxConcurrency) and merged in their original order.
+// With WithPartialResults, failed resolutions are skipped and reported by a *PartialResolutionError.
+func FetchAndMergeCoursePhaseWithResolution(coreURL string, authHeader string, coursePhaseID uuid.UUID, opts ...ResolutionOption) (promptTypes.MetaData, error) {
+	return FetchAndMergeCoursePhaseWithResolutionCtx(context.Background(), coreURL, authHeader, coursePhaseID, opts...)
+}
+
+// FetchAndMergeCoursePhaseWithResolutionCtx is FetchAndMergeCoursePhaseWithResolution bound to ctx,
+// e.g. the context of the incoming request. Cancelling ctx cancels all upstream requests.
+func FetchAndMergeCoursePhaseWithResolutionCtx(ctx context.Context, coreURL string, authHeader string, coursePhaseID uuid.UUID, opts ...ResolutionOption) (promptTypes.MetaData, error) {
+	config := newResolutionConfig(opts)
+	authHeader, err := config.authHeader(ctx, authHeader)
+	if err != nil {
+		return nil, err
+	}
 	url, err := url.JoinPath(coreURL, "api/course_phases", coursePhaseID.String(), "course_phase_data")
 	if err != nil {
 		return nil, err
 	}
-	data, err := FetchJSON(url, authHeader)
+	data, err := FetchJSONCtx(ctx, url, authHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -208,14 +291,21 @@ func FetchAndMergeCoursePhaseWithResolution(coreURL string, authHeader string, c
 		cpWithRes.PrevData = make(promptTypes.MetaData)
 	}
 
-	for _, res := range cpWithRes.Resolutions {
-		resolvedData, err := ResolveCoursePhaseData(authHeader, res)
-		if err != nil {
-			return nil, err
+	allResolvedData, resErrs := resolveConcurrently(ctx, cpWithRes.Resolutions, config.maxConcurrency, !config.partialResults, func(ctx context.Context, res Resolution) (interface{}, error) {
+		return ResolveCoursePhaseDataCtx(ctx, authHeader, res)
+	})
+	resolutionErr := config.resolutionError(resErrs)
+	if resolutionErr != nil && !config.partialResults {
+		return nil, resolutionErr
+	}
+
+	for resIdx, res := range cpWithRes.Resolutions {
+		if resErrs[resIdx] != nil {
+			continue
 		}
-		cpWithRes.PrevData[res.DtoName] = resolvedData
+		cpWithRes.PrevData[res.DtoName] = allResolvedData[resIdx]
 	}
-	return cpWithRes.PrevData, nil
+	return cpWithRes.PrevData, resolutionErr
 }
 
 // getEndpointPath trims leading and trailing slashes from the endpoint path.
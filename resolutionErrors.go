@@ -0,0 +1,56 @@
+package promptSDK
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ResolutionError reports which resolution failed.
+type ResolutionError struct {
+	DtoName string
+	URL     string
+	// StatusCode is the HTTP status of the upstream response, or 0 if no response was received.
+	StatusCode int
+	Err        error
+}
+
+func newResolutionError(res Resolution, url string, err error) *ResolutionError {
+	resErr := &ResolutionError{DtoName: res.DtoName, URL: url, Err: err}
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		resErr.StatusCode = upstreamErr.StatusCode
+	}
+	return resErr
+}
+
+func (e *ResolutionError) Error() string {
+	return fmt.Sprintf("failed to resolve %s from %s: %v", e.DtoName, e.URL, e.Err)
+}
+
+func (e *ResolutionError) Unwrap() error {
+	return e.Err
+}
+
+// PartialResolutionError is returned together with the merged data by the FetchAndMerge* helpers
+// in partial-results mode (see WithPartialResults) if some of the resolutions failed.
+// The data of the failed resolutions is missing from the merged result.
+type PartialResolutionError struct {
+	Failures []*ResolutionError
+}
+
+func (e *PartialResolutionError) Error() string {
+	dtoNames := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		dtoNames[i] = failure.DtoName
+	}
+	return fmt.Sprintf("%d resolution(s) failed: %s", len(e.Failures), strings.Join(dtoNames, ", "))
+}
+
+func (e *PartialResolutionError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure
+	}
+	return errs
+}
@@ -0,0 +1,136 @@
+package promptSDK
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+// DefaultResolutionConcurrency is the default number of resolutions requested in parallel.
+const DefaultResolutionConcurrency = 4
+
+// ResolutionOption configures the FetchAndMerge* helpers.
+type ResolutionOption func(*resolutionConfig)
+
+type resolutionConfig struct {
+	maxConcurrency int
+	partialResults bool
+	authProvider   utils.AuthProvider
+}
+
+func newResolutionConfig(opts []ResolutionOption) resolutionConfig {
+	config := resolutionConfig{maxConcurrency: DefaultResolutionConcurrency}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.maxConcurrency < 1 {
+		config.maxConcurrency = 1
+	}
+	return config
+}
+
+// WithMaxConcurrency limits the number of resolutions requested in parallel. 1 resolves them one after another.
+func WithMaxConcurrency(maxConcurrency int) ResolutionOption {
+	return func(c *resolutionConfig) {
+		c.maxConcurrency = maxConcurrency
+	}
+}
+
+// WithPartialResults returns the merged data even if some resolutions failed. The failures are reported
+// by a *PartialResolutionError returned together with the data; failures of the core request are still fatal.
+func WithPartialResults() ResolutionOption {
+	return func(c *resolutionConfig) {
+		c.partialResults = true
+	}
+}
+
+// WithAuthProvider sends the core request and all resolutions with the Authorization header of auth instead of
+// the passed authHeader, e.g. with a ClientCredentialsTokenSource in background jobs without a user.
+func WithAuthProvider(auth utils.AuthProvider) ResolutionOption {
+	return func(c *resolutionConfig) {
+		c.authProvider = auth
+	}
+}
+
+// authHeader returns the Authorization header of the configured AuthProvider, or authHeader if none is configured.
+func (c resolutionConfig) authHeader(ctx context.Context, authHeader string) (string, error) {
+	if c.authProvider == nil {
+		return authHeader, nil
+	}
+	return c.authProvider.AuthHeader(ctx)
+}
+
+// resolutionError returns the error of the FetchAndMerge* helpers for the failed resolutions:
+// nil if all succeeded, a *PartialResolutionError in partial-results mode and the joined failures otherwise.
+func (c resolutionConfig) resolutionError(resErrs []*ResolutionError) error {
+	var failures []*ResolutionError
+	for _, resErr := range resErrs {
+		if resErr != nil {
+			failures = append(failures, resErr)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	partialErr := &PartialResolutionError{Failures: failures}
+	if c.partialResults {
+		return partialErr
+	}
+	return errors.Join(partialErr.Unwrap()...)
+}
+
+// resolveConcurrently calls resolve for every resolution with at most maxConcurrency calls in flight.
+// The results and errors are returned in the order of the resolutions; the error of a successful
+// resolution is nil. The URL of a failed resolution is built from extraPaths.
+// Once ctx is done, the remaining resolutions are not started and fail with the context's error.
+// With failFast, the first failure cancels the remaining resolutions, which then have no error of their own.
+func resolveConcurrently[T any](parent context.Context, resolutions []Resolution, maxConcurrency int, failFast bool, resolve func(context.Context, Resolution) (T, error), extraPaths ...string) ([]T, []*ResolutionError) {
+	results := make([]T, len(resolutions))
+	errs := make([]*ResolutionError, len(resolutions))
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	resolutionError := func(res Resolution, err error) *ResolutionError {
+		if failFast && errors.Is(err, context.Canceled) && parent.Err() == nil {
+			// cancelled because of an earlier failure
+			return nil
+		}
+		if failFast {
+			cancel()
+		}
+		return newResolutionError(res, buildURL(res, extraPaths...), err)
+	}
+
+	semaphore := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for idx, res := range resolutions {
+		if err := ctx.Err(); err != nil {
+			errs[idx] = resolutionError(res, err)
+			continue
+		}
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			errs[idx] = resolutionError(res, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			result, err := resolve(ctx, res)
+			if err != nil {
+				errs[idx] = resolutionError(res, err)
+				return
+			}
+			results[idx] = result
+		}()
+	}
+	wg.Wait()
+
+	return results, errs
+}
@@ -0,0 +1,162 @@
+package promptSDK
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResolutions(n int) []Resolution {
+	resolutions := make([]Resolution, n)
+	for i := range resolutions {
+		resolutions[i] = Resolution{
+			DtoName:       fmt.Sprintf("dto%d", i),
+			BaseURL:       "https://module.example.com",
+			EndpointPath:  "resolution",
+			CoursePhaseID: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+		}
+	}
+	return resolutions
+}
+
+func TestResolveConcurrently_KeepsOrderAndRespectsLimit(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	resolutions := testResolutions(10)
+
+	results, resErrs := resolveConcurrently(context.Background(), resolutions, 3, false, func(_ context.Context, res Resolution) (string, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return res.DtoName, nil
+	})
+
+	require.NoError(t, newResolutionConfig(nil).resolutionError(resErrs))
+	for i, result := range results {
+		assert.Equal(t, resolutions[i].DtoName, result)
+	}
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+}
+
+func TestResolveConcurrently_AggregatesErrors(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	resolutions := testResolutions(4)
+
+	_, resErrs := resolveConcurrently(context.Background(), resolutions, 2, false, func(_ context.Context, res Resolution) (string, error) {
+		switch res.DtoName {
+		case "dto1":
+			return "", errUnavailable
+		case "dto3":
+			return "", &UpstreamError{URL: "https://module.example.com", StatusCode: 503}
+		}
+		return res.DtoName, nil
+	}, "participation")
+
+	require.Len(t, resErrs, 4)
+	assert.Nil(t, resErrs[0])
+	assert.Nil(t, resErrs[2])
+
+	err := newResolutionConfig(nil).resolutionError(resErrs)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errUnavailable)
+
+	var resErr *ResolutionError
+	require.ErrorAs(t, err, &resErr)
+	assert.Equal(t, "dto1", resErr.DtoName, "errors are reported in the order of the resolutions")
+	assert.Equal(t, "https://module.example.com/course_phase/123e4567-e89b-12d3-a456-426614174000/resolution/participation", resErr.URL)
+	assert.Contains(t, err.Error(), "dto3")
+	assert.Equal(t, 503, resErrs[3].StatusCode)
+}
+
+func TestResolutionError_PartialResults(t *testing.T) {
+	failure := &ResolutionError{DtoName: "interview", StatusCode: 502, Err: errors.New("bad gateway")}
+
+	err := newResolutionConfig([]ResolutionOption{WithPartialResults()}).resolutionError([]*ResolutionError{nil, failure})
+
+	var partialErr *PartialResolutionError
+	require.ErrorAs(t, err, &partialErr)
+	assert.Equal(t, []*ResolutionError{failure}, partialErr.Failures)
+	assert.Equal(t, "1 resolution(s) failed: interview", err.Error())
+	assert.NoError(t, newResolutionConfig([]ResolutionOption{WithPartialResults()}).resolutionError([]*ResolutionError{nil}))
+}
+
+func TestNewResolutionConfig(t *testing.T) {
+	assert.Equal(t, DefaultResolutionConcurrency, newResolutionConfig(nil).maxConcurrency)
+	assert.Equal(t, 8, newResolutionConfig([]ResolutionOption{WithMaxConcurrency(8)}).maxConcurrency)
+	assert.Equal(t, 1, newResolutionConfig([]ResolutionOption{WithMaxConcurrency(0)}).maxConcurrency)
+}
+
+func TestResolveConcurrently_StopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var started atomic.Int32
+
+	_, resErrs := resolveConcurrently(ctx, testResolutions(5), 1, false, func(_ context.Context, res Resolution) (string, error) {
+		started.Add(1)
+		cancel()
+		return res.DtoName, nil
+	})
+
+	assert.ErrorIs(t, newResolutionConfig(nil).resolutionError(resErrs), context.Canceled)
+	assert.Less(t, started.Load(), int32(5))
+}
+
+func TestWithAuthProvider(t *testing.T) {
+	var mu sync.Mutex
+	var authHeaders []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "course_phase_data") {
+			_ = json.NewEncoder(w).Encode(PrevCoursePhaseData{Resolutions: []Resolution{{
+				DtoName: "teams", BaseURL: server.URL, EndpointPath: "teams", CoursePhaseID: uuid.New(),
+			}}})
+			return
+		}
+		_, _ = w.Write([]byte(`{"teams": []}`))
+	}))
+	defer server.Close()
+
+	_, err := FetchAndMergeCoursePhaseWithResolutionCtx(context.Background(), server.URL, "", uuid.New(),
+		WithAuthProvider(utils.StaticAuthHeader("Bearer service")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer service", "Bearer service"}, authHeaders)
+}
+
+func TestResolveConcurrently_FailFastCancelsRemainingResolutions(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	var started atomic.Int32
+
+	_, resErrs := resolveConcurrently(context.Background(), testResolutions(5), 2, true, func(ctx context.Context, res Resolution) (string, error) {
+		started.Add(1)
+		if res.DtoName == "dto0" {
+			return "", errUnavailable
+		}
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	err := newResolutionConfig(nil).resolutionError(resErrs)
+	require.ErrorIs(t, err, errUnavailable)
+	assert.NotErrorIs(t, err, context.Canceled, "cancelled resolutions are not reported")
+	assert.Less(t, started.Load(), int32(5), "resolutions after the failure are not started")
+}
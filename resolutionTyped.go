@@ -0,0 +1,105 @@
+package promptSDK
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/prompt-sdk/utils"
+)
+
+// MissingDtoError is returned by the typed resolution functions if a response does not contain the DtoName key.
+type MissingDtoError struct {
+	DtoName string
+	// CourseParticipationID is set if the entry of a single participation in ResolveAllParticipationsAs lacks the key.
+	CourseParticipationID uuid.UUID
+}
+
+func (e *MissingDtoError) Error() string {
+	if e.CourseParticipationID != uuid.Nil {
+		return fmt.Sprintf("failed to find expected key in response for participation %s: %s", e.CourseParticipationID, e.DtoName)
+	}
+	return fmt.Sprintf("failed to find expected key in response: %s", e.DtoName)
+}
+
+// ResolveParticipationAs resolves data for a single course participation and decodes the DTO into T.
+// The decoded value is validated with the shared `binding` validator.
+func ResolveParticipationAs[T any](ctx context.Context, authHeader string, resolution Resolution, courseParticipationID uuid.UUID) (T, error) {
+	data, err := FetchJSONCtx(ctx, buildURL(resolution, courseParticipationID.String()), authHeader)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decodeDto[T](data, resolution.DtoName)
+}
+
+// ResolveCoursePhaseDataAs resolves data for a course phase and decodes the DTO into T.
+// The decoded value is validated with the shared `binding` validator.
+func ResolveCoursePhaseDataAs[T any](ctx context.Context, authHeader string, resolution Resolution) (T, error) {
+	data, err := FetchJSONCtx(ctx, buildURL(resolution), authHeader)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decodeDto[T](data, resolution.DtoName)
+}
+
+// ResolveAllParticipationsAs resolves data for all participations and decodes the DTO of each into T,
+// keyed by courseParticipationID. Every decoded value is validated with the shared `binding` validator.
+func ResolveAllParticipationsAs[T any](ctx context.Context, authHeader string, resolution Resolution) (map[uuid.UUID]T, error) {
+	data, err := FetchJSONCtx(ctx, buildURL(resolution), authHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	results := make(map[uuid.UUID]T, len(items))
+	for _, item := range items {
+		var participationID uuid.UUID
+		if err := json.Unmarshal(item["courseParticipationID"], &participationID); err != nil {
+			return nil, fmt.Errorf("failed to parse courseParticipationID: %w", err)
+		}
+
+		raw, ok := item[resolution.DtoName]
+		if !ok {
+			return nil, &MissingDtoError{DtoName: resolution.DtoName, CourseParticipationID: participationID}
+		}
+		value, err := decodeAndValidate[T](raw, resolution.DtoName)
+		if err != nil {
+			return nil, fmt.Errorf("participation %s: %w", participationID, err)
+		}
+		results[participationID] = value
+	}
+	return results, nil
+}
+
+// decodeDto decodes the value of the dtoName key of a JSON object into T.
+func decodeDto[T any](data []byte, dtoName string) (T, error) {
+	var zero T
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(data, &result); err != nil {
+		return zero, err
+	}
+
+	raw, ok := result[dtoName]
+	if !ok {
+		return zero, &MissingDtoError{DtoName: dtoName}
+	}
+	return decodeAndValidate[T](raw, dtoName)
+}
+
+func decodeAndValidate[T any](raw json.RawMessage, dtoName string) (T, error) {
+	var value T
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, fmt.Errorf("failed to decode %s: %w", dtoName, err)
+	}
+	if err := utils.ValidateValue(value); err != nil {
+		return value, fmt.Errorf("invalid %s: %w", dtoName, err)
+	}
+	return value, nil
+}
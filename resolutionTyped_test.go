@@ -0,0 +1,80 @@
+package promptSDK
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testScore struct {
+	Score   float64 `json:"score"`
+	Grader  string  `json:"grader" binding:"required"`
+	Comment string  `json:"comment"`
+}
+
+func newTypedTestServer(t *testing.T, body string) Resolution {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return Resolution{DtoName: "score", BaseURL: server.URL, EndpointPath: "scores", CoursePhaseID: uuid.New()}
+}
+
+func TestResolveCoursePhaseDataAs(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    testScore
+		wantErr string
+	}{
+		{"decodes DTO", `{"score":{"score":1.7,"grader":"ab12cde"}}`, testScore{Score: 1.7, Grader: "ab12cde"}, ""},
+		{"missing DTO key", `{"other":{}}`, testScore{}, "failed to find expected key in response: score"},
+		{"invalid DTO", `{"score":{"score":1.7}}`, testScore{}, "invalid score"},
+		{"wrong type", `{"score":"1.7"}`, testScore{}, "failed to decode score"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			res := newTypedTestServer(t, tt.body)
+			got, err := ResolveCoursePhaseDataAs[testScore](context.Background(), "Bearer token", res)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveAllParticipationsAs(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	res := newTypedTestServer(t, `[
+		{"courseParticipationID":"`+first.String()+`","score":{"score":1.0,"grader":"ab12cde"}},
+		{"courseParticipationID":"`+second.String()+`","score":{"score":2.3,"grader":"ab12cde"}}
+	]`)
+
+	got, err := ResolveAllParticipationsAs[testScore](context.Background(), "Bearer token", res)
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]testScore{
+		first:  {Score: 1.0, Grader: "ab12cde"},
+		second: {Score: 2.3, Grader: "ab12cde"},
+	}, got)
+
+	missing := newTypedTestServer(t, `[{"courseParticipationID":"`+first.String()+`"}]`)
+	_, err = ResolveAllParticipationsAs[testScore](context.Background(), "Bearer token", missing)
+	var missingErr *MissingDtoError
+	require.ErrorAs(t, err, &missingErr)
+	assert.Equal(t, MissingDtoError{DtoName: "score", CourseParticipationID: first}, *missingErr)
+}
+
+func TestResolveParticipationAs_Slice(t *testing.T) {
+	res := newTypedTestServer(t, `{"score":[{"score":1.0,"grader":"ab12cde"},{"score":2.0}]}`)
+	_, err := ResolveParticipationAs[[]testScore](context.Background(), "Bearer token", res, uuid.New())
+	require.ErrorContains(t, err, "Grader", "slice elements are validated")
+}
@@ -0,0 +1,141 @@
+package promptSDK_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	promptSDK "github.com/ls1intum/prompt-sdk"
+	"github.com/ls1intum/prompt-sdk/coretest"
+	"github.com/ls1intum/prompt-sdk/promptTypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The contract tests check the merged output of the FetchAndMerge* helpers against the JSON documented on them.
+
+var (
+	contractCoursePhaseID = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	contractTeamPhaseID   = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	contractModulePhaseID = uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	contractParticipation = uuid.MustParse("44444444-4444-4444-4444-444444444444")
+	contractOther         = uuid.MustParse("55555555-5555-5555-5555-555555555555")
+	contractStudentID     = uuid.MustParse("66666666-6666-6666-6666-666666666666")
+)
+
+var contractOptions = map[string][]promptSDK.ResolutionOption{
+	"default":    nil,
+	"sequential": {promptSDK.WithMaxConcurrency(1)},
+	"partial":    {promptSDK.WithPartialResults()},
+}
+
+func newContractServers(t *testing.T) *coretest.Server {
+	t.Helper()
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+	module := coretest.NewModuleServer()
+	t.Cleanup(module.Close)
+
+	core.AddParticipation(contractCoursePhaseID, promptTypes.CoursePhaseParticipationWithStudent{
+		CoursePhaseID:         contractCoursePhaseID,
+		CourseParticipationID: contractParticipation,
+		PassStatus:            "not_assessed",
+		PrevData:              promptTypes.MetaData{"applicationScore": 5.0},
+		Student: promptTypes.Student{
+			Person: promptTypes.Person{ID: contractStudentID, FirstName: "Ada", LastName: "Lovelace"},
+			Email:  "ada@tum.de",
+		},
+	})
+	core.AddParticipation(contractCoursePhaseID, promptTypes.CoursePhaseParticipationWithStudent{
+		CoursePhaseID:         contractCoursePhaseID,
+		CourseParticipationID: contractOther,
+	})
+	core.AddResolution(contractCoursePhaseID, module.Resolution(contractModulePhaseID, "/scores/", "score"))
+	core.AddResolution(contractCoursePhaseID, module.Resolution(contractModulePhaseID, "interviews", "interview"))
+	module.AddParticipationData(contractModulePhaseID, "scores", "score", contractParticipation, map[string]interface{}{"grade": 1.3})
+	module.AddParticipationData(contractModulePhaseID, "scores", "score", contractOther, map[string]interface{}{"grade": 2.0})
+	module.AddParticipationData(contractModulePhaseID, "interviews", "interview", contractParticipation, []string{"went well"})
+
+	core.SetCoursePhaseData(contractTeamPhaseID, promptTypes.MetaData{"maxTeamSize": 5.0})
+	core.AddResolution(contractTeamPhaseID, module.Resolution(contractModulePhaseID, "teams", "teams"))
+	module.SetCoursePhaseData(contractModulePhaseID, "teams", "teams", []map[string]string{{"name": "Team 1"}})
+	return core
+}
+
+func toJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestContract_FetchAndMergeParticipationsWithResolutions(t *testing.T) {
+	core := newContractServers(t)
+	for name, opts := range contractOptions {
+		t.Run(name, func(t *testing.T) {
+			participations, err := promptSDK.FetchAndMergeParticipationsWithResolutions(core.URL(), "Bearer token", contractCoursePhaseID, opts...)
+			require.NoError(t, err)
+			require.Len(t, participations, 2)
+
+			assert.JSONEq(t, `{
+				"applicationScore": 5,
+				"score": {"grade": 1.3},
+				"interview": ["went well"]
+			}`, toJSON(t, participations[0].PrevData))
+			assert.JSONEq(t, `{"score": {"grade": 2}}`, toJSON(t, participations[1].PrevData),
+				"participations without an entry in a resolution are left unchanged")
+		})
+	}
+}
+
+func TestContract_FetchAndMergeCourseParticipationWithResolution(t *testing.T) {
+	core := newContractServers(t)
+	for name, opts := range contractOptions {
+		t.Run(name, func(t *testing.T) {
+			participation, err := promptSDK.FetchAndMergeCourseParticipationWithResolution(core.URL(), "Bearer token", contractCoursePhaseID, contractParticipation, opts...)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, `{
+				"coursePhaseID": "11111111-1111-1111-1111-111111111111",
+				"courseParticipationID": "44444444-4444-4444-4444-444444444444",
+				"passStatus": "not_assessed",
+				"restrictedData": null,
+				"studentReadableData": null,
+				"prevData": {
+					"applicationScore": 5,
+					"score": {"grade": 1.3},
+					"interview": ["went well"]
+				},
+				"student": {
+					"id": "66666666-6666-6666-6666-666666666666",
+					"firstName": "Ada",
+					"lastName": "Lovelace",
+					"email": "ada@tum.de",
+					"matriculationNumber": "",
+					"universityLogin": "",
+					"hasUniversityAccount": false,
+					"gender": "",
+					"nationality": "",
+					"studyDegree": "",
+					"studyProgram": "",
+					"currentSemester": null
+				}
+			}`, toJSON(t, participation))
+		})
+	}
+}
+
+func TestContract_FetchAndMergeCoursePhaseWithResolution(t *testing.T) {
+	core := newContractServers(t)
+	for name, opts := range contractOptions {
+		t.Run(name, func(t *testing.T) {
+			prevData, err := promptSDK.FetchAndMergeCoursePhaseWithResolution(core.URL(), "Bearer token", contractTeamPhaseID, opts...)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, `{
+				"maxTeamSize": 5,
+				"teams": [{"name": "Team 1"}]
+			}`, toJSON(t, prevData))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package promptSDK_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	promptSDK "github.com/ls1intum/prompt-sdk"
+	"github.com/ls1intum/prompt-sdk/coretest"
+	"github.com/ls1intum/prompt-sdk/promptTypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchAndMergeParticipationsWithResolutions_PartialResults(t *testing.T) {
+	core := coretest.NewServer()
+	t.Cleanup(core.Close)
+	coursePhaseID := uuid.New()
+	courseParticipationID := uuid.New()
+
+	available := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"courseParticipationID":"` + courseParticipationID.String() + `","score":4}]`))
+	}))
+	t.Cleanup(available.Close)
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(unavailable.Close)
+
+	core.AddParticipation(coursePhaseID, promptTypes.CoursePhaseParticipationWithStudent{
+		CoursePhaseID:         coursePhaseID,
+		CourseParticipationID: courseParticipationID,
+	})
+	core.AddResolution(coursePhaseID, promptSDK.Resolution{DtoName: "score", BaseURL: available.URL, EndpointPath: "scores", CoursePhaseID: uuid.New()})
+	core.AddResolution(coursePhaseID, promptSDK.Resolution{DtoName: "interview", BaseURL: unavailable.URL, EndpointPath: "interviews", CoursePhaseID: uuid.New()})
+
+	participations, err := promptSDK.FetchAndMergeParticipationsWithResolutions(core.URL(), "Bearer token", coursePhaseID)
+	require.Error(t, err)
+	assert.Nil(t, participations, "without partial results a failing resolution discards everything")
+
+	participations, err = promptSDK.FetchAndMergeParticipationsWithResolutions(core.URL(), "Bearer token", coursePhaseID, promptSDK.WithPartialResults())
+	var partialErr *promptSDK.PartialResolutionError
+	require.ErrorAs(t, err, &partialErr)
+	require.Len(t, partialErr.Failures, 1)
+	assert.Equal(t, "interview", partialErr.Failures[0].DtoName)
+	assert.Equal(t, http.StatusInternalServerError, partialErr.Failures[0].StatusCode)
+	assert.Contains(t, partialErr.Failures[0].URL, unavailable.URL)
+
+	require.Len(t, participations, 1)
+	assert.Equal(t, 4.0, participations[0].PrevData["score"])
+	assert.NotContains(t, participations[0].PrevData, "interview")
+}
@@ -0,0 +1,75 @@
+// Package legacyKeysAnalyzer reports reads of the deprecated untyped gin context keys set by the
+// authentication middlewares, such as c.Get("userRoles"), together with their typed replacement.
+//
+// Run it on a module with the legacyKeys command in tools/legacyKeys, standalone or as vet tool:
+//
+//	legacyKeys ./...
+//	go vet -vettool=$(which legacyKeys) ./...
+package legacyKeysAnalyzer
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+
+	"github.com/ls1intum/prompt-sdk/keycloakTokenVerifier"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const ginPackage = "github.com/gin-gonic/gin"
+
+// replacements maps the legacy context keys to their typed replacement.
+var replacements = keycloakTokenVerifier.LegacyContextKeys()
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "legacykeys",
+	Doc:      "reports reads of the deprecated gin context keys of the prompt-sdk authentication middlewares, e.g. c.Get(\"userRoles\")",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || len(call.Args) == 0 || !isGetter(selector.Sel.Name) || !isGinContext(pass.TypesInfo.TypeOf(selector.X)) {
+			return
+		}
+
+		key := pass.TypesInfo.Types[call.Args[0]].Value
+		if key == nil || key.Kind() != constant.String {
+			return
+		}
+		if replacement, legacy := replacements[constant.StringVal(key)]; legacy {
+			pass.Reportf(call.Pos(), "gin context key %s is deprecated, use %s instead", key.ExactString(), replacement)
+		}
+	})
+	return nil, nil
+}
+
+// isGetter reports whether the method reads a context key: Get, MustGet, the typed GetString, GetBool, ... and Value.
+// Request accessors such as GetQuery, GetHeader or GetPostForm are not getters of context keys.
+func isGetter(method string) bool {
+	switch method {
+	case "Get", "MustGet", "GetString", "GetBool", "GetFloat64", "GetTime", "GetDuration", "GetStringSlice", "Value":
+		return true
+	}
+	return strings.HasPrefix(method, "GetInt") || strings.HasPrefix(method, "GetUint") || strings.HasPrefix(method, "GetStringMap")
+}
+
+// isGinContext reports whether t is gin.Context or *gin.Context.
+func isGinContext(t types.Type) bool {
+	if pointer, ok := t.(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == "Context" && obj.Pkg() != nil && obj.Pkg().Path() == ginPackage
+}
@@ -0,0 +1,29 @@
+package a
+
+import "github.com/gin-gonic/gin"
+
+const rolesKey = "userRoles"
+
+type cache struct{}
+
+func (cache) Get(key string) (any, bool) { return nil, false }
+
+func handler(c *gin.Context) {
+	c.Get("userRoles")                     // want `gin context key "userRoles" is deprecated, use GetTokenUser\(c\).Roles instead`
+	c.Get(rolesKey)                        // want `gin context key "userRoles" is deprecated`
+	_ = c.GetBool("isLecturer")            // want `gin context key "isLecturer" is deprecated, use GetTokenUser\(c\).IsLecturer instead`
+	_ = c.GetString("userID")              // want `gin context key "userID" is deprecated, use UserID\(c\) instead`
+	_ = c.MustGet("courseParticipationID") // want `gin context key "courseParticipationID" is deprecated, use CourseParticipationID\(c\) instead`
+
+	_ = c.GetInt64("userID") // want `gin context key "userID" is deprecated, use UserID\(c\) instead`
+
+	c.Get("tokenUser")
+	c.GetQuery("userID")
+	_ = c.GetHeader("firstName")
+	c.GetPostForm("lastName")
+	c.Set("userRoles", nil)
+	_ = c.Param("userID")
+	cache{}.Get("userRoles")
+	var key string
+	c.Get(key)
+}
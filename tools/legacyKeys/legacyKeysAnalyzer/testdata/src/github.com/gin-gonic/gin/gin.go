@@ -0,0 +1,16 @@
+// Package gin is a minimal stand-in for github.com/gin-gonic/gin in the analyzer tests.
+package gin
+
+type Context struct{}
+
+func (c *Context) Get(key any) (any, bool)  { return nil, false }
+func (c *Context) MustGet(key any) any      { return nil }
+func (c *Context) GetBool(key any) bool     { return false }
+func (c *Context) GetString(key any) string { return "" }
+func (c *Context) Set(key any, value any)   {}
+func (c *Context) Param(key string) string  { return "" }
+
+func (c *Context) GetInt64(key any) int64                { return 0 }
+func (c *Context) GetQuery(key string) (string, bool)    { return "", false }
+func (c *Context) GetHeader(key string) string           { return "" }
+func (c *Context) GetPostForm(key string) (string, bool) { return "", false }
@@ -0,0 +1,16 @@
+// Command legacyKeys reports reads of the deprecated gin context keys of the authentication middlewares,
+// e.g. c.Get("userRoles"), and names their typed replacement. It is a separate module, so that the SDK
+// does not depend on golang.org/x/tools. Build it from a checkout of the SDK and run it in the consumer module:
+//
+//	go build -C tools/legacyKeys -o "$PWD/legacyKeys" .
+//	cd ../my-module && go vet -vettool=../prompt-sdk/legacyKeys ./...
+package main
+
+import (
+	"github.com/ls1intum/prompt-sdk/tools/legacyKeys/legacyKeysAnalyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(legacyKeysAnalyzer.Analyzer)
+}
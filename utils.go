@@ -8,6 +8,37 @@ import (
 	"github.com/ls1intum/prompt-sdk/utils"
 )
 
+// UpstreamError is returned by FetchJSON and the core requests if the upstream service responds with a non-200 status.
+// It carries the URL, the method, the status code, the beginning of the body and whether the request is retryable.
+type UpstreamError = utils.UpstreamError
+
+// HTTPClientConfig configures the HTTP client shared by FetchJSON, the resolutions and the authentication middleware.
+type HTTPClientConfig = utils.HTTPClientConfig
+
+// HTTPMetrics are hooks to export metrics of the shared HTTP client.
+type HTTPMetrics = utils.HTTPMetrics
+
+// ErrCircuitOpen is returned if the circuit breaker of the upstream host is open.
+var ErrCircuitOpen = utils.ErrCircuitOpen
+
+// ConfigureHTTPClient replaces the shared HTTP client. Call it before InitAuthenticationMiddleware.
+func ConfigureHTTPClient(config HTTPClientConfig) {
+	utils.ConfigureHTTPClient(config)
+}
+
+// AuthProvider supplies the Authorization header of outgoing requests, see WithAuthProvider.
+type AuthProvider = utils.AuthProvider
+
+// ClientCredentialsConfig configures a ClientCredentialsTokenSource.
+type ClientCredentialsConfig = utils.ClientCredentialsConfig
+
+// ClientCredentialsTokenSource obtains and renews client-credentials tokens of the module's Keycloak client.
+type ClientCredentialsTokenSource = utils.ClientCredentialsTokenSource
+
+func NewClientCredentialsTokenSource(config ClientCredentialsConfig) *ClientCredentialsTokenSource {
+	return utils.NewClientCredentialsTokenSource(config)
+}
+
 func CORSMiddleware(clientHost string) gin.HandlerFunc {
 	return utils.CORS(clientHost)
 }
@@ -23,3 +54,11 @@ func GetEnv(key, defaultValue string) string {
 func FetchJSON(url, authHeader string) ([]byte, error) {
 	return utils.FetchJSON(url, authHeader)
 }
+
+func FetchJSONCtx(ctx context.Context, url, authHeader string) ([]byte, error) {
+	return utils.FetchJSONCtx(ctx, url, authHeader)
+}
+
+func FetchJSONWithAuth(ctx context.Context, url string, auth AuthProvider) ([]byte, error) {
+	return utils.FetchJSONWithAuth(ctx, url, auth)
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by the SDK HTTP client if the circuit breaker of the target host is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitState is the state of the circuit breaker of a single host.
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests pass.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all requests until the cooldown has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request pass; its result closes or re-opens the circuit.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// circuitBreaker opens after threshold consecutive failures and allows a probe after cooldown.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	onChange  func(from, to CircuitState)
+
+	mu            sync.Mutex
+	state         CircuitState
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+// allow reports whether a request may be sent.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	notify := noChange
+	defer func() { notify() }()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		notify = b.setState(CircuitHalfOpen)
+		b.probeInFlight = true
+		return true
+	case CircuitHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of a request that was allowed.
+func (b *circuitBreaker) record(failure bool) {
+	b.mu.Lock()
+	notify := noChange
+	defer func() { notify() }()
+	defer b.mu.Unlock()
+
+	b.probeInFlight = false
+	if !failure {
+		b.failures = 0
+		notify = b.setState(CircuitClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		notify = b.setState(CircuitOpen)
+	}
+}
+
+// release gives up a permit without recording a result, e.g. if the caller cancelled the request.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probeInFlight = false
+}
+
+func noChange() {}
+
+// setState changes the state and returns a function that reports the change to onChange.
+// The caller must hold b.mu and call the returned function after releasing it,
+// so that onChange may use the breaker or the client.
+func (b *circuitBreaker) setState(state CircuitState) func() {
+	if b.state == state {
+		return noChange
+	}
+	from := b.state
+	b.state = state
+	if b.onChange == nil {
+		return noChange
+	}
+	return func() { b.onChange(from, state) }
+}
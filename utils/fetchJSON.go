@@ -1,32 +1,47 @@
 package utils
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"net/http"
-	"time"
 )
 
 // fetchJSON performs an HTTP GET request to the given URL with the auth header,
 // checks for a successful response, and returns the body.
+// A non-200 response is returned as *UpstreamError.
 func FetchJSON(url, authHeader string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+	return FetchJSONCtx(context.Background(), url, authHeader)
+}
+
+// FetchJSONCtx is FetchJSON bound to ctx, e.g. the context of the incoming request,
+// so that cancellation and deadlines propagate to the upstream request.
+// The request is sent with the shared client of HTTPClient.
+func FetchJSONCtx(ctx context.Context, url, authHeader string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Authorization", authHeader)
 
-	resp, err := (&http.Client{
-		Timeout: 10 * time.Second,
-	}).Do(req)
+	resp, err := HTTPClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 response: %d", resp.StatusCode)
+		return nil, NewUpstreamError(resp)
 	}
 
 	return io.ReadAll(resp.Body)
 }
+
+// FetchJSONWithAuth is FetchJSONCtx with the Authorization header of auth,
+// e.g. a ClientCredentialsTokenSource for requests without a user.
+func FetchJSONWithAuth(ctx context.Context, url string, auth AuthProvider) ([]byte, error) {
+	authHeader, err := auth.AuthHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return FetchJSONCtx(ctx, url, authHeader)
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchJSONCtx_ForwardsAuthHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"authorization":"` + r.Header.Get("Authorization") + `"}`))
+	}))
+	defer server.Close()
+
+	data, err := FetchJSONCtx(context.Background(), server.URL, "Bearer token")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"authorization":"Bearer token"}`, string(data))
+}
+
+func TestFetchJSONCtx_CancelledContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := FetchJSONCtx(ctx, server.URL, "Bearer token")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFetchJSONCtx_UpstreamError(t *testing.T) {
+	body := strings.Repeat("x", 2*maxUpstreamErrorBody)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := HTTPClient()
+	SetHTTPClient(NewHTTPClient(HTTPClientConfig{MaxRetries: -1}))
+	defer SetHTTPClient(client)
+
+	_, err := FetchJSONCtx(context.Background(), server.URL, "Bearer token")
+	var upstreamErr *UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	require.Equal(t, http.StatusTooManyRequests, upstreamErr.StatusCode)
+	require.Equal(t, http.MethodGet, upstreamErr.Method)
+	require.Equal(t, server.URL, upstreamErr.URL)
+	require.Len(t, upstreamErr.Body, maxUpstreamErrorBody)
+	require.True(t, upstreamErr.Retryable)
+}
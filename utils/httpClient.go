@@ -0,0 +1,304 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultHTTPTimeout      = 10 * time.Second
+	defaultMaxRetries       = 2
+	defaultInitialBackoff   = 100 * time.Millisecond
+	defaultMaxBackoff       = 2 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+	defaultMaxIdleConns     = 100
+)
+
+// HTTPClientConfig configures the HTTP client shared by FetchJSON and the core requests of the authentication middleware.
+// Zero values fall back to the defaults noted on the fields.
+type HTTPClientConfig struct {
+	// Timeout limits a whole request including all retries (default 10s).
+	Timeout time.Duration
+	// MaxRetries is the number of retries of idempotent requests (default 2). Use a negative value to disable retries.
+	MaxRetries int
+	// InitialBackoff is the base of the exponential backoff between retries (default 100ms).
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff between retries (default 2s).
+	MaxBackoff time.Duration
+	// BreakerThreshold is the number of consecutive failures after which the circuit of a host opens (default 5).
+	// Use a negative value to disable the circuit breaker.
+	BreakerThreshold int
+	// BreakerCooldown is the time an open circuit rejects requests before a probe is let through (default 30s).
+	BreakerCooldown time.Duration
+	// Transport sends the requests. By default, a clone of http.DefaultTransport with more idle connections per host is used.
+	Transport http.RoundTripper
+	// Metrics receives the events of the client.
+	Metrics HTTPMetrics
+}
+
+// HTTPAttempt describes a single attempt of a request.
+type HTTPAttempt struct {
+	Method     string
+	Host       string
+	Attempt    int // starts at 0
+	StatusCode int // 0 if the request failed without a response
+	Err        error
+	Duration   time.Duration
+}
+
+// HTTPMetrics are hooks to export metrics of the SDK HTTP client. All hooks are optional and must not block.
+type HTTPMetrics struct {
+	// OnAttempt is called after every attempt of a request.
+	OnAttempt func(attempt HTTPAttempt)
+	// OnRetry is called before a failed attempt is retried after backoff.
+	OnRetry func(attempt HTTPAttempt, backoff time.Duration)
+	// OnCircuitRejected is called if a request is rejected because the circuit of the host is open.
+	OnCircuitRejected func(host string)
+	// OnCircuitStateChange is called if the circuit of a host changes its state.
+	OnCircuitStateChange func(host string, from, to CircuitState)
+}
+
+var sharedHTTPClient atomic.Pointer[http.Client]
+
+func init() {
+	sharedHTTPClient.Store(NewHTTPClient(HTTPClientConfig{}))
+}
+
+// HTTPClient returns the HTTP client shared by the SDK.
+func HTTPClient() *http.Client {
+	return sharedHTTPClient.Load()
+}
+
+// SetHTTPClient replaces the HTTP client shared by the SDK.
+// Passing nil restores a client with the default configuration.
+func SetHTTPClient(client *http.Client) {
+	if client == nil {
+		client = NewHTTPClient(HTTPClientConfig{})
+	}
+	sharedHTTPClient.Store(client)
+}
+
+// ConfigureHTTPClient replaces the HTTP client shared by the SDK with a new client built from config.
+// Verifiers pick up the shared client when they are created, so call it before initializing the authentication middleware.
+func ConfigureHTTPClient(config HTTPClientConfig) {
+	SetHTTPClient(NewHTTPClient(config))
+}
+
+// NewHTTPClient creates an HTTP client that retries idempotent requests with jittered exponential backoff
+// and runs a circuit breaker per host.
+func NewHTTPClient(config HTTPClientConfig) *http.Client {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultHTTPTimeout
+	}
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: NewResilientTransport(config),
+	}
+}
+
+// ResilientTransport is the http.RoundTripper behind NewHTTPClient.
+type ResilientTransport struct {
+	base             http.RoundTripper
+	maxRetries       int
+	initialBackoff   time.Duration
+	maxBackoff       time.Duration
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	metrics          HTTPMetrics
+	now              func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// NewResilientTransport creates the transport used by NewHTTPClient, e.g. to wrap it into another client.
+// The Timeout of config is ignored.
+func NewResilientTransport(config HTTPClientConfig) *ResilientTransport {
+	t := &ResilientTransport{
+		base:             config.Transport,
+		maxRetries:       config.MaxRetries,
+		initialBackoff:   config.InitialBackoff,
+		maxBackoff:       config.MaxBackoff,
+		breakerThreshold: config.BreakerThreshold,
+		breakerCooldown:  config.BreakerCooldown,
+		metrics:          config.Metrics,
+		now:              time.Now,
+		breakers:         make(map[string]*circuitBreaker),
+	}
+	if t.base == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = defaultMaxIdleConns
+		transport.MaxIdleConnsPerHost = defaultMaxIdleConns
+		t.base = transport
+	}
+	if t.maxRetries == 0 {
+		t.maxRetries = defaultMaxRetries
+	}
+	if t.initialBackoff <= 0 {
+		t.initialBackoff = defaultInitialBackoff
+	}
+	if t.maxBackoff <= 0 {
+		t.maxBackoff = defaultMaxBackoff
+	}
+	if t.breakerThreshold == 0 {
+		t.breakerThreshold = defaultBreakerThreshold
+	}
+	if t.breakerCooldown <= 0 {
+		t.breakerCooldown = defaultBreakerCooldown
+	}
+	return t
+}
+
+// CircuitState returns the state of the circuit breaker of host, e.g. "core.example.com:8080".
+func (t *ResilientTransport) CircuitState(host string) CircuitState {
+	t.mu.Lock()
+	breaker, ok := t.breakers[host]
+	t.mu.Unlock()
+	if !ok {
+		return CircuitClosed
+	}
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	return breaker.state
+}
+
+// RoundTrip sends the request with retries. The circuit breaker of the host records one outcome per request,
+// the one of the last attempt, so that the retries of a single request cannot open the circuit.
+func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	breaker := t.breaker(host)
+	if breaker != nil && !breaker.allow() {
+		if t.metrics.OnCircuitRejected != nil {
+			t.metrics.OnCircuitRejected(host)
+		}
+		return nil, fmt.Errorf("%w for host %s", ErrCircuitOpen, host)
+	}
+
+	resp, err := t.roundTripWithRetries(req, host)
+	if breaker == nil {
+		return resp, err
+	}
+	// A request cancelled by the caller says nothing about the health of the host, a timeout does.
+	if errors.Is(req.Context().Err(), context.Canceled) {
+		breaker.release()
+	} else {
+		breaker.record(err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	}
+	return resp, err
+}
+
+func (t *ResilientTransport) roundTripWithRetries(req *http.Request, host string) (*http.Response, error) {
+	canRetry := isIdempotent(req.Method) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		start := t.now()
+		resp, err := t.base.RoundTrip(attemptReq)
+		info := HTTPAttempt{Method: req.Method, Host: host, Attempt: attempt, Err: err, Duration: t.now().Sub(start)}
+		if resp != nil {
+			info.StatusCode = resp.StatusCode
+		}
+		if t.metrics.OnAttempt != nil {
+			t.metrics.OnAttempt(info)
+		}
+
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+
+		if !canRetry || attempt >= t.maxRetries || !isRetryable(resp, err) {
+			return resp, err
+		}
+
+		backoff := t.backoff(attempt)
+		if t.metrics.OnRetry != nil {
+			t.metrics.OnRetry(info, backoff)
+		}
+		if resp != nil {
+			// Drain the body so that the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+		if err := sleep(req.Context(), backoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *ResilientTransport) breaker(host string) *circuitBreaker {
+	if t.breakerThreshold < 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	breaker, ok := t.breakers[host]
+	if !ok {
+		breaker = &circuitBreaker{threshold: t.breakerThreshold, cooldown: t.breakerCooldown, now: t.now}
+		if t.metrics.OnCircuitStateChange != nil {
+			breaker.onChange = func(from, to CircuitState) {
+				t.metrics.OnCircuitStateChange(host, from, to)
+			}
+		}
+		t.breakers[host] = breaker
+	}
+	return breaker
+}
+
+// backoff returns the exponential backoff of attempt with full jitter.
+func (t *ResilientTransport) backoff(attempt int) time.Duration {
+	backoff := t.maxBackoff
+	if attempt < 30 {
+		backoff = min(t.initialBackoff<<attempt, t.maxBackoff)
+	}
+	return rand.N(backoff) + 1
+}
+
+// rewindRequest returns req for the first attempt and a copy with a fresh body for retries.
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRetryable(resp *http.Response, err error) bool {
+	return err != nil || IsRetryableStatus(resp.StatusCode)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
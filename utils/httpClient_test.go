@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestResilientTransport_RetriesIdempotentRequests(t *testing.T) {
+	server, requests := newStatusServer(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	var retries atomic.Int32
+	client := NewHTTPClient(HTTPClientConfig{
+		InitialBackoff: time.Millisecond,
+		Metrics: HTTPMetrics{
+			OnRetry: func(HTTPAttempt, time.Duration) { retries.Add(1) },
+		},
+	})
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, int32(2), retries.Load())
+}
+
+func TestResilientTransport_DoesNotRetryPost(t *testing.T) {
+	server, requests := newStatusServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	client := NewHTTPClient(HTTPClientConfig{InitialBackoff: time.Millisecond})
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestResilientTransport_DoesNotRetryClientErrors(t *testing.T) {
+	server, requests := newStatusServer(t, http.StatusNotFound, http.StatusOK)
+	client := NewHTTPClient(HTTPClientConfig{InitialBackoff: time.Millisecond})
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestResilientTransport_CircuitBreaker(t *testing.T) {
+	server, requests := newStatusServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var changes []CircuitState
+	transport := NewResilientTransport(HTTPClientConfig{
+		MaxRetries:       -1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+		Metrics: HTTPMetrics{
+			OnCircuitStateChange: func(_ string, _, to CircuitState) { changes = append(changes, to) },
+		},
+	})
+	transport.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	for range 2 {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, CircuitOpen, transport.CircuitState(host))
+
+	_, err := client.Get(server.URL)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), requests.Load(), "an open circuit must not send requests")
+
+	now = now.Add(time.Minute)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, CircuitClosed, transport.CircuitState(host))
+	assert.Equal(t, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}, changes)
+}
+
+func TestResilientTransport_CircuitBreakerCountsRequestsNotAttempts(t *testing.T) {
+	server, requests := newStatusServer(t, http.StatusServiceUnavailable)
+	host := strings.TrimPrefix(server.URL, "http://")
+	var transport *ResilientTransport
+	var observed []CircuitState
+	transport = NewResilientTransport(HTTPClientConfig{
+		InitialBackoff:   time.Millisecond,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+		Metrics: HTTPMetrics{
+			// the hook is called without holding the breaker, so it may read the state
+			OnCircuitStateChange: func(host string, _, _ CircuitState) { observed = append(observed, transport.CircuitState(host)) },
+		},
+	})
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, CircuitClosed, transport.CircuitState(host), "the retries of one request count as one failure")
+
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, CircuitOpen, transport.CircuitState(host))
+	assert.Equal(t, []CircuitState{CircuitOpen}, observed)
+}
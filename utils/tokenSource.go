@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthProvider returns the Authorization header of outgoing requests, e.g. the forwarded header
+// of the user (StaticAuthHeader) or a client-credentials token of the module (ClientCredentialsTokenSource).
+type AuthProvider interface {
+	AuthHeader(ctx context.Context) (string, error)
+}
+
+// StaticAuthHeader is an AuthProvider that always returns the same header, e.g. the one of the incoming request.
+type StaticAuthHeader string
+
+func (h StaticAuthHeader) AuthHeader(context.Context) (string, error) {
+	return string(h), nil
+}
+
+const (
+	// defaultRefreshBefore is how long before its expiry a client-credentials token is renewed.
+	defaultRefreshBefore = 30 * time.Second
+	// defaultTokenLifetime is assumed for token responses without expires_in.
+	defaultTokenLifetime = 5 * time.Minute
+	// tokenRequestTimeout bounds a token request, which is shared by all waiting callers.
+	tokenRequestTimeout = 30 * time.Second
+)
+
+// ClientCredentialsConfig configures a ClientCredentialsTokenSource.
+type ClientCredentialsConfig struct {
+	// TokenURL is the token endpoint of the realm, see KeycloakTokenURL.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Scopes are requested in addition to the default scopes of the client.
+	Scopes []string
+	// RefreshBefore renews the token this long before it expires (default 30s), at most after half its lifetime.
+	RefreshBefore time.Duration
+	// HTTPClient sends the token requests (default the shared client of HTTPClient).
+	HTTPClient *http.Client
+}
+
+// KeycloakTokenURL returns the token endpoint of a Keycloak realm.
+func KeycloakTokenURL(keycloakURL, realm string) (string, error) {
+	return url.JoinPath(keycloakURL, "realms", realm, "protocol/openid-connect/token")
+}
+
+// ClientCredentialsTokenSource obtains access tokens of the module's own Keycloak client with the
+// client-credentials grant, for requests without a user, e.g. from background jobs.
+// Tokens are cached and renewed shortly before they expire; tokens without expires_in are kept for 5 minutes.
+// It is safe for concurrent use: concurrent callers share a single token request.
+type ClientCredentialsTokenSource struct {
+	config ClientCredentialsConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+	// fetch is the token request in flight, if any.
+	fetch *tokenFetch
+}
+
+// tokenFetch is a token request shared by the callers of Token. token and err are set before done is closed.
+type tokenFetch struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// NewClientCredentialsTokenSource creates a token source for the client. No token is requested until the first use.
+func NewClientCredentialsTokenSource(config ClientCredentialsConfig) *ClientCredentialsTokenSource {
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = defaultRefreshBefore
+	}
+	return &ClientCredentialsTokenSource{config: config, now: time.Now}
+}
+
+// Token returns a valid access token, requesting a new one if the cached token is missing or about to expire.
+// A non-200 response of the token endpoint is returned as *UpstreamError.
+// The request is not cancelled with ctx, as other callers may wait for it; ctx only ends the wait of this caller.
+func (s *ClientCredentialsTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	if s.token != "" && s.now().Before(s.refreshAt) {
+		token := s.token
+		s.mu.Unlock()
+		return token, nil
+	}
+	fetch := s.fetch
+	if fetch == nil {
+		fetch = &tokenFetch{done: make(chan struct{})}
+		s.fetch = fetch
+		go s.runFetch(context.WithoutCancel(ctx), fetch)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.token, fetch.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// runFetch requests a token, caches it and passes it to the callers waiting for fetch.
+func (s *ClientCredentialsTokenSource) runFetch(ctx context.Context, fetch *tokenFetch) {
+	ctx, cancel := context.WithTimeout(ctx, tokenRequestTimeout)
+	defer cancel()
+	token, lifetime, err := s.requestToken(ctx)
+
+	s.mu.Lock()
+	s.fetch = nil
+	if err == nil {
+		if lifetime <= 0 {
+			lifetime = defaultTokenLifetime
+		}
+		s.token = token
+		s.refreshAt = s.now().Add(lifetime - min(s.config.RefreshBefore, lifetime/2))
+	}
+	s.mu.Unlock()
+
+	fetch.token, fetch.err = token, err
+	close(fetch.done)
+}
+
+// AuthHeader returns the access token as Bearer Authorization header.
+func (s *ClientCredentialsTokenSource) AuthHeader(ctx context.Context) (string, error) {
+	token, err := s.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
+// Invalidate drops the cached token, e.g. after a request was rejected with 401, so that the next call requests a new one.
+func (s *ClientCredentialsTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (s *ClientCredentialsTokenSource) requestToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+
+	client := s.config.HTTPClient
+	if client == nil {
+		client = HTTPClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, NewUpstreamError(resp)
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", 0, err
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.New("token response without access_token")
+	}
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || clientID != "module" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		n := requests.Add(1)
+		_, _ = w.Write([]byte(`{"access_token":"token-` + strconv.Itoa(int(n)) + `","expires_in":` + strconv.Itoa(expiresIn) + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestClientCredentialsTokenSource_CachesToken(t *testing.T) {
+	server, requests := newTokenServer(t, 300)
+	source := NewClientCredentialsTokenSource(ClientCredentialsConfig{TokenURL: server.URL, ClientID: "module", ClientSecret: "secret"})
+
+	for range 3 {
+		header, err := source.AuthHeader(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-1", header)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+
+	source.Invalidate()
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+}
+
+func TestClientCredentialsTokenSource_RefreshesBeforeExpiry(t *testing.T) {
+	server, requests := newTokenServer(t, 300)
+	source := NewClientCredentialsTokenSource(ClientCredentialsConfig{TokenURL: server.URL, ClientID: "module", ClientSecret: "secret"})
+	now := time.Now()
+	source.now = func() time.Time { return now }
+
+	_, err := source.Token(context.Background())
+	require.NoError(t, err)
+
+	now = now.Add(4*time.Minute + 29*time.Second)
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	now = now.Add(2 * time.Second)
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestClientCredentialsTokenSource_InvalidClient(t *testing.T) {
+	server, _ := newTokenServer(t, 300)
+	source := NewClientCredentialsTokenSource(ClientCredentialsConfig{TokenURL: server.URL, ClientID: "module", ClientSecret: "wrong"})
+
+	_, err := source.AuthHeader(context.Background())
+	var upstreamErr *UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusUnauthorized, upstreamErr.StatusCode)
+	assert.Contains(t, upstreamErr.Body, "invalid_client")
+}
+
+func TestFetchJSONWithAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"authorization":"` + r.Header.Get("Authorization") + `"}`))
+	}))
+	defer server.Close()
+
+	data, err := FetchJSONWithAuth(context.Background(), server.URL, StaticAuthHeader("Bearer service"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"authorization":"Bearer service"}`, string(data))
+}
+
+func TestKeycloakTokenURL(t *testing.T) {
+	tokenURL, err := KeycloakTokenURL("https://keycloak.example.com/", "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, "https://keycloak.example.com/realms/prompt/protocol/openid-connect/token", tokenURL)
+}
+
+func TestClientCredentialsTokenSource_WithoutExpiresIn(t *testing.T) {
+	server, requests := newTokenServer(t, 0)
+	source := NewClientCredentialsTokenSource(ClientCredentialsConfig{TokenURL: server.URL, ClientID: "module", ClientSecret: "secret"})
+
+	for range 3 {
+		token, err := source.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestClientCredentialsTokenSource_SharesRequest(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_in":300}`))
+	}))
+	t.Cleanup(server.Close)
+	source := NewClientCredentialsTokenSource(ClientCredentialsConfig{TokenURL: server.URL, ClientID: "module", ClientSecret: "secret"})
+
+	// a caller giving up does not cancel the request of the others
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := source.Token(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token", token)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), requests.Load())
+}
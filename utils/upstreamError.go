@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxUpstreamErrorBody is the number of bytes of the response body kept in an UpstreamError.
+const maxUpstreamErrorBody = 1024
+
+// UpstreamError is returned by FetchJSON and the core requests if the upstream service responds with a non-200 status.
+type UpstreamError struct {
+	URL        string
+	Method     string
+	StatusCode int
+	// Body is the beginning of the response body, truncated to 1 KiB.
+	Body string
+	// Retryable reports whether the request may succeed if it is sent again later, e.g. on 503.
+	Retryable bool
+}
+
+// NewUpstreamError creates an UpstreamError from a non-200 response. It reads but does not close the body.
+func NewUpstreamError(resp *http.Response) *UpstreamError {
+	upstreamErr := &UpstreamError{
+		StatusCode: resp.StatusCode,
+		Retryable:  IsRetryableStatus(resp.StatusCode),
+	}
+	if resp.Request != nil {
+		upstreamErr.Method = resp.Request.Method
+		upstreamErr.URL = resp.Request.URL.String()
+	}
+	if resp.Body != nil {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxUpstreamErrorBody))
+		upstreamErr.Body = string(body)
+	}
+	return upstreamErr
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("received non-200 response: %d from %s %s", e.StatusCode, e.Method, e.URL)
+}
+
+// IsRetryableStatus reports whether a response with the status may succeed if the request is sent again later.
+func IsRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -47,6 +47,7 @@ package utils
 
 import (
 	"fmt"
+	"reflect"
 	"unicode"
 
 	"github.com/gin-gonic/gin/binding"
@@ -88,6 +89,27 @@ func ValidateStruct(s interface{}) error {
 	return validate.Struct(s)
 }
 
+// ValidateValue validates structs (and pointers to structs) like ValidateStruct and the elements
+// of slices, arrays and maps with "dive". All other values are considered valid.
+func ValidateValue(v interface{}) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		return validate.Struct(value.Interface())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return validate.Var(value.Interface(), "dive")
+	default:
+		return nil
+	}
+}
+
 // MatriculationNumberValidator checks if a string is a valid matriculation number.
 // A valid matriculation number must:
 //   - Be exactly 8 characters long